@@ -1,19 +1,28 @@
-// Package http exists only to modify the dial function of the default http
-// transport, allowing all clients that rely on it to establish output
-// connections when compiled to GOOS=wasip1.
+// Package http configures HTTP clients and servers to use the WASI socket
+// extensions when compiled to GOOS=wasip1.
 //
-// This package is intended to be imported as a nameless package with:
+// Importing the package modifies the dial function of the default http
+// transport, allowing all clients that rely on it to establish output
+// connections. For this purpose, the package can be imported as a nameless
+// package with:
 //
 //	import (
 //		_ "github.com/stealthrocket/net/http"
 //	)
 //
-// Note that only the default transport (http.DefaultTransport) can be altered,
-// other instances of http.Transport created by the program will default to use
-// the standard library's net package, which does not have the ability to open
-// network connections. Programs that create new transports must configure the
-// dial function by setting the DialContext field to wasip1.DialContext.
+// Note that importing the package only alters the default transport
+// (http.DefaultTransport), other instances of http.Transport created by the
+// program default to use the standard library's net package, which does not
+// have the ability to open network connections. Programs that create new
+// transports can use NewTransport or NewClient to obtain transports configured
+// to use the WASI socket extensions, or ConfigureTransport to adapt transports
+// created by other libraries.
+//
+// Servers can be created with ListenAndServe, ListenAndServeTLS, or the Server
+// type, which replace their counterparts of the standard library's net/http
+// package. ListenAndServeH2C and Server.EnableH2C support serving HTTP/2 over
+// cleartext connections, such as gRPC requests.
 //
 // When compiling to other targets than GOOS=wasip1, importing this package has
-// no effect.
+// no effect and the package is empty.
 package http
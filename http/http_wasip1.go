@@ -3,13 +3,232 @@
 package http
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/stealthrocket/net/wasip1"
+	"golang.org/x/net/http/httpproxy"
 )
 
 func init() {
 	if t, ok := http.DefaultTransport.(*http.Transport); ok {
-		t.DialContext = wasip1.DialContext
+		ConfigureTransport(t)
+		t.Proxy = ProxyFromEnvironment
 	}
 }
+
+// ProxyFromEnvironment is like http.ProxyFromEnvironment but it also supports
+// the ALL_PROXY environment variable (or all_proxy), which is used for HTTP
+// and HTTPS requests when HTTP_PROXY or HTTPS_PROXY are not set. The
+// precedence is the same as wasip1.ProxyDialerFromEnvironment, which applies
+// it to arbitrary connections.
+//
+// The proxy URLs may use the "http", "https", or "socks5" schemes; the
+// connections to the proxies are established with the WASI socket extensions
+// by transports created by this package.
+//
+// The environment is read once, on the first call.
+func ProxyFromEnvironment(req *http.Request) (*url.URL, error) {
+	return envProxyFunc()(req.URL)
+}
+
+var envProxyFunc = sync.OnceValue(func() func(*url.URL) (*url.URL, error) {
+	config := httpproxy.FromEnvironment()
+	allProxy := os.Getenv("ALL_PROXY")
+	if allProxy == "" {
+		allProxy = os.Getenv("all_proxy")
+	}
+	if config.HTTPProxy == "" {
+		config.HTTPProxy = allProxy
+	}
+	if config.HTTPSProxy == "" {
+		config.HTTPSProxy = allProxy
+	}
+	return config.ProxyFunc()
+})
+
+// Options contains the configuration of transports and clients created by
+// NewTransport and NewClient.
+//
+// The zero-value is a valid configuration which uses the same defaults as
+// http.DefaultTransport.
+type Options struct {
+	// Dialer is used to establish network connections. When nil, a dialer
+	// with a 30 seconds timeout is used.
+	Dialer *wasip1.Dialer
+
+	// TLSClientConfig is the TLS configuration of the transport, it is cloned
+	// before being used. When RootCAs is nil, the certificates returned by
+	// wasip1.SystemCertPool are used, or those loaded from RootCAsPath.
+	TLSClientConfig *tls.Config
+
+	// RootCAsPath is the path of a file or directory containing the PEM
+	// encoded root certificates trusted by the transport. The path must be
+	// within a directory preopened by the WebAssembly runtime. It is ignored
+	// when TLSClientConfig sets RootCAs.
+	RootCAsPath string
+
+	// Proxy returns the proxy to use for a given request. When nil, proxies
+	// are configured from the environment with ProxyFromEnvironment; set it
+	// to a function returning a nil URL to disable proxies.
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// DisableHTTP2 prevents the transport from negotiating HTTP/2 on TLS
+	// connections.
+	DisableHTTP2 bool
+
+	// Connection limits, see http.Transport for details. Zero values select
+	// the defaults of http.DefaultTransport.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+
+	// Timeouts, see http.Transport for details. Zero values select the
+	// defaults of http.DefaultTransport.
+	IdleConnTimeout       time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	ExpectContinueTimeout time.Duration
+
+	// Timeout is the time limit for requests made by clients created with
+	// NewClient. Zero means no timeout.
+	Timeout time.Duration
+}
+
+// NewTransport returns a http.Transport configured to establish connections
+// with the WASI socket extensions.
+//
+// The opts argument may be nil to use the default configuration.
+//
+// If the root certificates cannot be loaded from opts.RootCAsPath, the
+// transport fails establishing TLS connections with the error which occurred.
+func NewTransport(opts *Options) *http.Transport {
+	if opts == nil {
+		opts = new(Options)
+	}
+	dialer := opts.Dialer
+	if dialer == nil {
+		dialer = &wasip1.Dialer{Timeout: 30 * time.Second}
+	}
+	t := &http.Transport{
+		Proxy:                 opts.Proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     !opts.DisableHTTP2,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		ExpectContinueTimeout: opts.ExpectContinueTimeout,
+	}
+	if opts.TLSClientConfig != nil {
+		t.TLSClientConfig = opts.TLSClientConfig.Clone()
+	} else {
+		t.TLSClientConfig = new(tls.Config)
+	}
+	if c := t.TLSClientConfig; c.RootCAs == nil && !c.InsecureSkipVerify {
+		configureRootCAs(c, opts.RootCAsPath)
+	}
+	if t.Proxy == nil {
+		t.Proxy = ProxyFromEnvironment
+	}
+	if t.MaxIdleConns == 0 {
+		t.MaxIdleConns = 100
+	}
+	if t.IdleConnTimeout == 0 {
+		t.IdleConnTimeout = 90 * time.Second
+	}
+	if t.TLSHandshakeTimeout == 0 {
+		t.TLSHandshakeTimeout = 10 * time.Second
+	}
+	if t.ExpectContinueTimeout == 0 {
+		t.ExpectContinueTimeout = 1 * time.Second
+	}
+	if opts.DisableHTTP2 {
+		// A non-nil empty map disables the automatic HTTP/2 upgrade, see the
+		// documentation of http.Transport.
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	return t
+}
+
+func configureRootCAs(c *tls.Config, path string) {
+	var roots *x509.CertPool
+	var err error
+	if path != "" {
+		roots, err = wasip1.LoadCertPool(path)
+	} else {
+		roots, err = wasip1.SystemCertPool()
+	}
+	if err != nil {
+		// Report the error on each connection rather than silently falling
+		// back to roots that the application did not ask for. The callback
+		// set by the application, if any, still runs first.
+		verify := c.VerifyConnection
+		c.VerifyConnection = func(cs tls.ConnectionState) error {
+			if verify != nil {
+				if err := verify(cs); err != nil {
+					return err
+				}
+			}
+			return fmt.Errorf("loading root certificates: %w", err)
+		}
+		return
+	}
+	c.RootCAs = roots
+}
+
+// NewClient returns a http.Client using a transport created by NewTransport.
+//
+// The opts argument may be nil to use the default configuration.
+func NewClient(opts *Options) *http.Client {
+	c := &http.Client{Transport: NewTransport(opts)}
+	if opts != nil {
+		c.Timeout = opts.Timeout
+	}
+	return c
+}
+
+// ConfigureTransport modifies t to establish connections with the WASI socket
+// extensions, and returns it.
+//
+// This function is useful to adapt transports created by libraries that do
+// not allow configuring their dial function. The other fields of t are left
+// unchanged, except for DialContext and Dial which are replaced, and would
+// otherwise use the standard library's net package.
+//
+// When the TLS configuration of t does not set RootCAs, the certificates
+// returned by wasip1.SystemCertPool are installed when the transport
+// establishes its first connection, which lets fallback roots registered by
+// init functions, such as those of the rootcerts package, be taken into
+// account. The TLS configuration is cloned first, so configurations shared
+// with other transports are not modified.
+func ConfigureTransport(t *http.Transport) *http.Transport {
+	if t.TLSClientConfig != nil {
+		t.TLSClientConfig = t.TLSClientConfig.Clone()
+	} else {
+		t.TLSClientConfig = new(tls.Config)
+	}
+	var once sync.Once
+	t.Dial = nil
+	t.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		// The transport clones its TLS configuration after dialing, the
+		// roots are always set before they are used.
+		once.Do(func() {
+			if c := t.TLSClientConfig; c != nil && c.RootCAs == nil && !c.InsecureSkipVerify {
+				configureRootCAs(c, "")
+			}
+		})
+		return wasip1.DialContext(ctx, network, address)
+	}
+	return t
+}
@@ -3,33 +3,32 @@
 package http_test
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
 	"io"
+	"net"
 	"net/http"
-	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
-	_ "github.com/stealthrocket/net/http"
+	wasihttp "github.com/stealthrocket/net/http"
+	"github.com/stealthrocket/net/http/httptest"
 	"github.com/stealthrocket/net/wasip1"
+	"golang.org/x/net/http2"
 )
 
 func TestHTTP(t *testing.T) {
-	// We must use NewUnstartedServer instead of NewServer otherwise the server
-	// attempts to open a listener but it does not know that it has to use the
-	// wasip1.Listen function.
-	server := httptest.NewUnstartedServer(
+	server := httptest.NewServer(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			io.WriteString(w, "Hello, World!\n")
 		}),
 	)
 	defer server.Close()
 
-	l, err := wasip1.Listen("tcp", ":0")
-	if err != nil {
-		t.Fatal(err)
-	}
-	server.Listener = l
-	server.Start()
-
 	r, err := http.Get(server.URL + "/")
 	if err != nil {
 		t.Fatal(err)
@@ -44,3 +43,200 @@ func TestHTTP(t *testing.T) {
 		t.Errorf("wrong http response received: %q", b)
 	}
 }
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewUnstartedServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, r.Proto)
+		}),
+	)
+	server.EnableHTTP2 = true
+	httptest.StartTLS(server)
+	defer server.Close()
+
+	tlsConfig := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	tests := []struct {
+		scenario string
+		client   *http.Client
+		proto    string
+	}{
+		{
+			scenario: "http/2",
+			client:   wasihttp.NewClient(&wasihttp.Options{TLSClientConfig: tlsConfig}),
+			proto:    "HTTP/2.0",
+		},
+
+		{
+			scenario: "http/1.1",
+			client: wasihttp.NewClient(&wasihttp.Options{
+				TLSClientConfig: tlsConfig,
+				DisableHTTP2:    true,
+			}),
+			proto: "HTTP/1.1",
+		},
+
+		{
+			scenario: "retrofit",
+			client: &http.Client{
+				Transport: wasihttp.ConfigureTransport(&http.Transport{
+					TLSClientConfig: tlsConfig,
+				}),
+			},
+			proto: "HTTP/1.1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			r, err := test.client.Get(server.URL + "/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Body.Close()
+
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.proto {
+				t.Errorf("wrong protocol: want=%q got=%q", test.proto, b)
+			}
+		})
+	}
+}
+
+func TestRootCAsPath(t *testing.T) {
+	server := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "OK")
+		}),
+	)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := wasihttp.NewClient(&wasihttp.Options{RootCAsPath: path})
+	r, err := client.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+
+	// The error loading the root certificates is reported after calling the
+	// VerifyConnection callback of the application.
+	verified := false
+	client = wasihttp.NewClient(&wasihttp.Options{
+		RootCAsPath: path + ".missing",
+		TLSClientConfig: &tls.Config{
+			VerifyConnection: func(tls.ConnectionState) error {
+				verified = true
+				return nil
+			},
+		},
+	})
+	if r, err := client.Get(server.URL + "/"); err == nil {
+		r.Body.Close()
+		t.Fatal("the request succeeded without loading the root certificates")
+	} else if !strings.Contains(err.Error(), "loading root certificates") {
+		t.Fatalf("wrong error: %v", err)
+	}
+	if !verified {
+		t.Error("the VerifyConnection callback of the application was not called")
+	}
+}
+
+func TestConfigureTransportRootCAs(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	transport := wasihttp.ConfigureTransport(&http.Transport{})
+	defer transport.CloseIdleConnections()
+
+	// The server uses a self-signed certificate which is not part of the
+	// system roots.
+	client := &http.Client{Transport: transport}
+	if r, err := client.Get(server.URL + "/"); err == nil {
+		r.Body.Close()
+		t.Fatal("the request succeeded with an untrusted certificate")
+	}
+	if c := transport.TLSClientConfig; c.RootCAs == nil && c.VerifyConnection == nil {
+		t.Fatal("the root certificates were not configured")
+	}
+}
+
+func TestServerH2C(t *testing.T) {
+	server := &wasihttp.Server{
+		Server: http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, r.Proto)
+			}),
+		},
+		EnableH2C: true,
+	}
+
+	l, err := wasip1.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errch := make(chan error, 1)
+	go func() { errch <- server.Serve(l) }()
+
+	h2c := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return wasip1.DialContext(ctx, network, addr)
+			},
+		},
+	}
+
+	tests := []struct {
+		scenario string
+		client   *http.Client
+		proto    string
+	}{
+		{
+			scenario: "http/1.1",
+			client:   wasihttp.NewClient(nil),
+			proto:    "HTTP/1.1",
+		},
+
+		{
+			scenario: "h2c",
+			client:   h2c,
+			proto:    "HTTP/2.0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			r, err := test.client.Get("http://" + l.Addr().String() + "/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Body.Close()
+
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.proto {
+				t.Errorf("wrong protocol: want=%q got=%q", test.proto, b)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errch; err != http.ErrServerClosed {
+		t.Fatalf("wrong error returned by Serve: %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+// Package httptest provides replacements for the server constructors of the
+// standard library's net/http/httptest package, which listen with the WASI
+// socket extensions when compiled to GOOS=wasip1.
+//
+// The functions return values of the standard httptest.Server type, so test
+// suites written for the standard library only need to change their import
+// path to run under WebAssembly runtimes such as wasirun:
+//
+//	import (
+//		"github.com/stealthrocket/net/http/httptest"
+//	)
+//
+//	server := httptest.NewServer(handler)
+//	defer server.Close()
+//
+//	r, err := server.Client().Get(server.URL)
+//
+// When compiling to other targets than GOOS=wasip1, the functions forward to
+// the standard library's net/http/httptest package.
+package httptest
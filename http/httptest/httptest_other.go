@@ -0,0 +1,36 @@
+//go:build !wasip1
+
+package httptest
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// Server is an alias of the standard library's httptest.Server type.
+type Server = httptest.Server
+
+// NewServer calls httptest.NewServer.
+func NewServer(handler http.Handler) *Server {
+	return httptest.NewServer(handler)
+}
+
+// NewTLSServer calls httptest.NewTLSServer.
+func NewTLSServer(handler http.Handler) *Server {
+	return httptest.NewTLSServer(handler)
+}
+
+// NewUnstartedServer calls httptest.NewUnstartedServer.
+func NewUnstartedServer(handler http.Handler) *Server {
+	return httptest.NewUnstartedServer(handler)
+}
+
+// Start calls s.Start.
+func Start(s *Server) {
+	s.Start()
+}
+
+// StartTLS calls s.StartTLS.
+func StartTLS(s *Server) {
+	s.StartTLS()
+}
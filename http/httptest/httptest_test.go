@@ -0,0 +1,65 @@
+package httptest_test
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stealthrocket/net/http/httptest"
+)
+
+func TestServer(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Proto)
+	})
+
+	tests := []struct {
+		scenario string
+		server   func() *httptest.Server
+		proto    string
+	}{
+		{
+			scenario: "http",
+			server:   func() *httptest.Server { return httptest.NewServer(handler) },
+			proto:    "HTTP/1.1",
+		},
+
+		{
+			scenario: "https",
+			server:   func() *httptest.Server { return httptest.NewTLSServer(handler) },
+			proto:    "HTTP/1.1",
+		},
+
+		{
+			scenario: "https+http2",
+			server: func() *httptest.Server {
+				s := httptest.NewUnstartedServer(handler)
+				s.EnableHTTP2 = true
+				httptest.StartTLS(s)
+				return s
+			},
+			proto: "HTTP/2.0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			server := test.server()
+			defer server.Close()
+
+			r, err := server.Client().Get(server.URL + "/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Body.Close()
+
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.proto {
+				t.Errorf("wrong protocol: want=%q got=%q", test.proto, b)
+			}
+		})
+	}
+}
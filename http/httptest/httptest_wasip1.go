@@ -0,0 +1,77 @@
+//go:build wasip1
+
+package httptest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	wasihttp "github.com/stealthrocket/net/http"
+	"github.com/stealthrocket/net/wasip1"
+)
+
+// Server is an alias of the standard library's httptest.Server type, allowing
+// test suites to use this package as a drop-in replacement.
+type Server = httptest.Server
+
+// NewServer starts and returns a new server listening on a loopback address.
+// The caller should call Close when finished, to shut it down.
+func NewServer(handler http.Handler) *Server {
+	s := NewUnstartedServer(handler)
+	Start(s)
+	return s
+}
+
+// NewTLSServer starts and returns a new server using TLS, listening on a
+// loopback address. The caller should call Close when finished, to shut it
+// down.
+func NewTLSServer(handler http.Handler) *Server {
+	s := NewUnstartedServer(handler)
+	StartTLS(s)
+	return s
+}
+
+// NewUnstartedServer returns a new server listening on a loopback address but
+// doesn't start it.
+//
+// After changing its configuration, the caller should call Start or StartTLS
+// from this package instead of the methods of httptest.Server, so the client
+// of the server is configured to use the WASI socket extensions.
+func NewUnstartedServer(handler http.Handler) *Server {
+	return &Server{
+		Listener: newLocalListener(),
+		Config:   &http.Server{Handler: handler},
+	}
+}
+
+// Start starts the server s, which must have been created by
+// NewUnstartedServer.
+func Start(s *Server) {
+	s.Start()
+	configureClient(s)
+}
+
+// StartTLS starts TLS on the server s, which must have been created by
+// NewUnstartedServer.
+func StartTLS(s *Server) {
+	s.StartTLS()
+	configureClient(s)
+}
+
+func configureClient(s *Server) {
+	if t, ok := s.Client().Transport.(*http.Transport); ok {
+		wasihttp.ConfigureTransport(t)
+	}
+}
+
+func newLocalListener() net.Listener {
+	l, err := wasip1.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		if l, err = wasip1.Listen("tcp6", "[::1]:0"); err != nil {
+			panic(fmt.Sprintf("httptest: failed to listen on a port: %v", err))
+		}
+	}
+	return l
+}
@@ -0,0 +1,125 @@
+//go:build wasip1
+
+package http
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/stealthrocket/net/wasip1"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// Server is a wrapper around http.Server which listens with the WASI socket
+// extensions.
+//
+// The ListenAndServe and ListenAndServeTLS methods of http.Server cannot be
+// used on GOOS=wasip1 because they depend on the standard library's net
+// package; Server overrides them. The other methods, including Shutdown, are
+// those of the embedded http.Server.
+type Server struct {
+	http.Server
+
+	// EnableH2C enables serving HTTP/2 over cleartext connections, which is
+	// commonly used by gRPC clients. HTTP/1 requests are still served on the
+	// same listener.
+	EnableH2C bool
+
+	once sync.Once
+	err  error
+}
+
+// ListenAndServe listens on the TCP address s.Addr and calls Serve to handle
+// requests on incoming connections. If s.Addr is blank, ":80" is used.
+func (s *Server) ListenAndServe() error {
+	l, err := s.listen(":80")
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// ListenAndServeTLS listens on the TCP address s.Addr and calls ServeTLS to
+// handle requests on incoming TLS connections. If s.Addr is blank, ":443" is
+// used.
+func (s *Server) ListenAndServeTLS(certFile, keyFile string) error {
+	l, err := s.listen(":443")
+	if err != nil {
+		return err
+	}
+	return s.ServeTLS(l, certFile, keyFile)
+}
+
+// Serve accepts incoming connections on the listener l, see http.Server.Serve
+// for details.
+func (s *Server) Serve(l net.Listener) error {
+	if err := s.configure(); err != nil {
+		l.Close()
+		return err
+	}
+	return s.Server.Serve(l)
+}
+
+// ServeTLS accepts incoming TLS connections on the listener l, see
+// http.Server.ServeTLS for details.
+func (s *Server) ServeTLS(l net.Listener, certFile, keyFile string) error {
+	if err := s.configure(); err != nil {
+		l.Close()
+		return err
+	}
+	return s.Server.ServeTLS(l, certFile, keyFile)
+}
+
+func (s *Server) listen(defaultAddr string) (net.Listener, error) {
+	addr := s.Addr
+	if addr == "" {
+		addr = defaultAddr
+	}
+	return wasip1.Listen("tcp", addr)
+}
+
+func (s *Server) configure() error {
+	s.once.Do(func() {
+		if !s.EnableH2C {
+			return
+		}
+		h2s := new(http2.Server)
+		// Configuring the server registers h2s for graceful shutdown, which
+		// is otherwise not supported by h2c connections since they are
+		// hijacked from the http server.
+		if s.err = http2.ConfigureServer(&s.Server, h2s); s.err != nil {
+			return
+		}
+		handler := s.Handler
+		if handler == nil {
+			handler = http.DefaultServeMux
+		}
+		s.Handler = h2c.NewHandler(handler, h2s)
+	})
+	return s.err
+}
+
+// ListenAndServe listens on the TCP address addr and serves requests with
+// handler. If handler is nil, http.DefaultServeMux is used.
+//
+// ListenAndServe always returns a non-nil error.
+func ListenAndServe(addr string, handler http.Handler) error {
+	s := &Server{Server: http.Server{Addr: addr, Handler: handler}}
+	return s.ListenAndServe()
+}
+
+// ListenAndServeTLS acts like ListenAndServe but it expects HTTPS connections,
+// using the certificate and private key from the given files.
+func ListenAndServeTLS(addr, certFile, keyFile string, handler http.Handler) error {
+	s := &Server{Server: http.Server{Addr: addr, Handler: handler}}
+	return s.ListenAndServeTLS(certFile, keyFile)
+}
+
+// ListenAndServeH2C acts like ListenAndServe but it also accepts HTTP/2
+// requests over cleartext connections.
+func ListenAndServeH2C(addr string, handler http.Handler) error {
+	s := &Server{Server: http.Server{Addr: addr, Handler: handler}, EnableH2C: true}
+	return s.ListenAndServe()
+}
@@ -0,0 +1,13 @@
+// Package http3 provides HTTP/3 clients and servers for programs compiled to
+// GOOS=wasip1, using https://pkg.go.dev/github.com/quic-go/quic-go on top of
+// the UDP sockets created by the github.com/stealthrocket/net/wasip1 package.
+//
+// The package is distributed as a separate module so only applications which
+// use HTTP/3 need to take a dependency on quic-go.
+//
+// Note that the WASI socket extensions do not give access to ancillary data,
+// so quic-go runs without ECN, GSO or batched reads; packets are sent and
+// received one at a time using the sock_send_to and sock_recv_from functions.
+//
+// When compiling to other targets than GOOS=wasip1, the package is empty.
+package http3
@@ -0,0 +1,137 @@
+//go:build wasip1
+
+package http3
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/stealthrocket/net/wasip1"
+)
+
+// ListenPacket creates a UDP socket bound to the local address which can be
+// used as the net.PacketConn of a quic.Transport or passed to the Serve method
+// of a http3.Server.
+func ListenPacket(network, address string) (net.PacketConn, error) {
+	c, err := wasip1.ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &packetConn{c}, nil
+}
+
+// NewTransport creates a quic.Transport bound to the local address.
+//
+// The returned transport owns the UDP socket, it is closed when the transport
+// is closed.
+func NewTransport(network, address string) (*quic.Transport, error) {
+	c, err := ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &quic.Transport{Conn: c}, nil
+}
+
+// Dial establishes a QUIC connection to the remote address, which is resolved
+// by the wasip1 package.
+//
+// A new UDP socket is created for each connection and closed when the
+// connection terminates.
+func Dial(ctx context.Context, address string, tlsConfig *tls.Config, quicConfig *quic.Config) (quic.EarlyConnection, error) {
+	c, err := wasip1.DialContext(ctx, "udp", address)
+	if err != nil {
+		return nil, err
+	}
+	pc := &packetConn{c.(net.PacketConn)}
+	tr := &quic.Transport{Conn: pc}
+	conn, err := tr.DialEarly(ctx, c.RemoteAddr(), tlsConfig, quicConfig)
+	if err != nil {
+		tr.Close()
+		pc.Close()
+		return nil, err
+	}
+	go func() {
+		<-conn.Context().Done()
+		tr.Close()
+		pc.Close()
+	}()
+	return conn, nil
+}
+
+// NewRoundTripper returns a HTTP/3 round tripper which establishes QUIC
+// connections with the wasip1 package.
+func NewRoundTripper(tlsConfig *tls.Config, quicConfig *quic.Config) *http3.RoundTripper {
+	return &http3.RoundTripper{
+		TLSClientConfig: tlsConfig,
+		QuicConfig:      quicConfig,
+		Dial:            Dial,
+	}
+}
+
+// NewClient returns a http.Client using a round tripper created by calling
+// NewRoundTripper.
+func NewClient(tlsConfig *tls.Config, quicConfig *quic.Config) *http.Client {
+	return &http.Client{Transport: NewRoundTripper(tlsConfig, quicConfig)}
+}
+
+// Serve accepts HTTP/3 connections on a UDP socket bound to the address
+// configured on the server, or ":https" if the address is empty.
+//
+// The server must have a TLS configuration.
+func Serve(server *http3.Server) error {
+	address := server.Addr
+	if address == "" {
+		address = ":https"
+	}
+	c, err := ListenPacket("udp", address)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return server.Serve(c)
+}
+
+// ListenAndServe listens on the UDP address and serves HTTP/3 requests with
+// the handler, using the certificate and key loaded from the given files.
+//
+// It is the equivalent of http3.ListenAndServeQUIC for GOOS=wasip1.
+func ListenAndServe(address, certFile, keyFile string, handler http.Handler) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+	return Serve(&http3.Server{
+		Addr:    address,
+		Handler: handler,
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+		}),
+	})
+}
+
+// packetConn wraps the UDP sockets of the wasip1 package to expose the buffer
+// sizing methods to quic-go, but hides the SyscallConn method because quic-go
+// has no way of inspecting buffer sizes on GOOS=wasip1 and would then report
+// that it failed to increase them.
+type packetConn struct{ net.PacketConn }
+
+func (c *packetConn) SetReadBuffer(bytes int) error {
+	if b, ok := c.PacketConn.(interface{ SetReadBuffer(int) error }); ok {
+		return b.SetReadBuffer(bytes)
+	}
+	return nil
+}
+
+func (c *packetConn) SetWriteBuffer(bytes int) error {
+	if b, ok := c.PacketConn.(interface{ SetWriteBuffer(int) error }); ok {
+		return b.SetWriteBuffer(bytes)
+	}
+	return nil
+}
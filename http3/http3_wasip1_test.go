@@ -0,0 +1,58 @@
+//go:build wasip1
+
+package http3_test
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/quic-go/quic-go/http3"
+	wasip1http3 "github.com/stealthrocket/net/http3"
+	"github.com/stealthrocket/net/internal/testcert"
+)
+
+func TestHTTP3(t *testing.T) {
+	cert, roots, err := testcert.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := wasip1http3.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	server := &http3.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "Hello, World!\n")
+		}),
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+		}),
+	}
+	defer server.Close()
+	go server.Serve(conn)
+
+	client := wasip1http3.NewClient(&tls.Config{RootCAs: roots}, nil)
+	defer client.Transport.(*http3.RoundTripper).Close()
+
+	r, err := client.Get("https://" + conn.LocalAddr().String() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "Hello, World!\n" {
+		t.Errorf("wrong http response received: %q", b)
+	}
+	if r.ProtoMajor != 3 {
+		t.Errorf("wrong protocol version: %s", r.Proto)
+	}
+}
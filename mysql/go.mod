@@ -4,5 +4,10 @@ go 1.21
 
 require (
 	github.com/go-sql-driver/mysql v1.7.2-0.20230613063930-943264b76442
-	github.com/stealthrocket/net v0.1.2
+	github.com/stealthrocket/net v0.3.0
+)
+
+require (
+	golang.org/x/net v0.23.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
 )
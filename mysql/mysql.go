@@ -7,6 +7,12 @@
 //		_ "github.com/stealthrocket/net/mysql"
 //	)
 //
+// Connections may then be opened to servers listening on TCP or unix sockets,
+// using DSNs such as "root:password@tcp(localhost:3306)/test" or
+// "root:password@unix(/var/run/mysqld/mysqld.sock)/test". The dialer can be
+// configured with Configure, and TLS configurations registered with
+// RegisterTLSConfig to use root certificates loaded by the wasip1 package.
+//
 // The package is distributed as a separate module so only applications which
 // use mysql need to take a dependency on this module.
 //
@@ -4,6 +4,7 @@ package mysql
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 
 	"github.com/go-sql-driver/mysql"
@@ -11,11 +12,46 @@ import (
 )
 
 func init() {
-	networks := []string{"tcp", "tcp4", "tcp6"}
+	Configure(new(wasip1.Dialer))
+}
+
+// Configure registers the dial functions of the mysql driver for the "tcp",
+// "tcp4", "tcp6", and "unix" networks to establish connections with dialer.
+//
+// This function is called when the package is imported, with a zero dialer.
+// Applications may call it again to apply settings such as timeouts or
+// keep-alives; the configuration applies to connections opened after the
+// call returns.
+func Configure(dialer *wasip1.Dialer) {
+	networks := []string{"tcp", "tcp4", "tcp6", "unix"}
 	for i := range networks {
 		network := networks[i]
 		mysql.RegisterDialContext(network, func(ctx context.Context, address string) (net.Conn, error) {
-			return wasip1.DialContext(ctx, network, address)
+			return dialer.DialContext(ctx, network, address)
 		})
 	}
 }
+
+// RegisterTLSConfig is like mysql.RegisterTLSConfig, but the root certificates
+// returned by wasip1.SystemCertPool are used when the configuration does not
+// set RootCAs. A nil configuration registers the default TLS settings.
+//
+// The registered configuration is selected by setting the tls parameter of
+// the DSN to key, for example:
+//
+//	root:password@tcp(localhost:3306)/test?tls=wasip1
+func RegisterTLSConfig(key string, config *tls.Config) error {
+	if config == nil {
+		config = new(tls.Config)
+	} else {
+		config = config.Clone()
+	}
+	if config.RootCAs == nil && !config.InsecureSkipVerify {
+		roots, err := wasip1.SystemCertPool()
+		if err != nil {
+			return err
+		}
+		config.RootCAs = roots
+	}
+	return mysql.RegisterTLSConfig(key, config)
+}
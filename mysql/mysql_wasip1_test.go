@@ -3,12 +3,16 @@
 package mysql_test
 
 import (
+	"crypto/tls"
 	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	// Importing this package configures go-sql-driver/mysql to use the dialer
 	// from github.com/stealthrocket/net/wasip1.
-	_ "github.com/stealthrocket/net/mysql"
+	"github.com/stealthrocket/net/mysql"
+	"github.com/stealthrocket/net/wasip1"
 )
 
 func TestMySQL(t *testing.T) {
@@ -27,3 +31,59 @@ func TestMySQL(t *testing.T) {
 		t.Errorf("wrong version returned by the mysql server: %q", ver)
 	}
 }
+
+func TestConfigure(t *testing.T) {
+	mysql.Configure(&wasip1.Dialer{Timeout: 10 * time.Second})
+	defer mysql.Configure(new(wasip1.Dialer))
+
+	db, err := sql.Open("mysql", "root:test@tcp(localhost:3306)/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegisterTLSConfig(t *testing.T) {
+	if err := mysql.RegisterTLSConfig("wasip1", &tls.Config{InsecureSkipVerify: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The mysql server uses a self-signed certificate, verification is
+	// disabled to test that the TLS configuration is found by the driver.
+	db, err := sql.Open("mysql", "root:test@tcp(localhost:3306)/test?tls=wasip1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegisterTLSConfigSystemRoots(t *testing.T) {
+	// Without RootCAs, the system roots are used to verify the certificate of
+	// the server, which is self-signed and must be rejected.
+	if err := mysql.RegisterTLSConfig("wasip1-verify", &tls.Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("mysql", "root:test@tcp(localhost:3306)/test?tls=wasip1-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = db.Ping()
+	if err == nil {
+		t.Fatal("the self-signed certificate of the server was trusted")
+	}
+	var verifyErr *tls.CertificateVerificationError
+	if !errors.As(err, &verifyErr) {
+		t.Fatalf("wrong error connecting to the server: %v", err)
+	}
+}
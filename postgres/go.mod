@@ -4,14 +4,15 @@ go 1.21
 
 require (
 	github.com/jackc/pgx/v5 v5.5.4
-	github.com/stealthrocket/net v0.1.3-0.20230609185417-24d7c1a5dd46
+	github.com/stealthrocket/net v0.3.0
 )
 
 require (
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
 	github.com/jackc/puddle/v2 v2.2.1 // indirect
-	golang.org/x/crypto v0.17.0 // indirect
+	golang.org/x/crypto v0.21.0 // indirect
+	golang.org/x/net v0.23.0 // indirect
 	golang.org/x/sync v0.1.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 )
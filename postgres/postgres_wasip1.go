@@ -1,3 +1,108 @@
 //go:build wasip1
 
 package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/stealthrocket/net/wasip1"
+)
+
+// DriverName is the name of the database/sql driver registered by this
+// package.
+const DriverName = "pgx-wasip1"
+
+func init() {
+	sql.Register(DriverName, new(Driver))
+}
+
+// Configure sets the dial and lookup functions of config to use the
+// github.com/stealthrocket/net/wasip1 package.
+func Configure(config *pgconn.Config) {
+	config.DialFunc = wasip1.DialContext
+	config.LookupFunc = wasip1.LookupHost
+}
+
+// ParseConfig is like pgx.ParseConfig but the returned configuration is set
+// up to open connections with the wasip1 package.
+func ParseConfig(connString string) (*pgx.ConnConfig, error) {
+	config, err := pgx.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	Configure(&config.Config)
+	return config, nil
+}
+
+// Connect establishes a connection to the postgres server described by
+// connString, see pgx.Connect for details.
+func Connect(ctx context.Context, connString string) (*pgx.Conn, error) {
+	config, err := ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.ConnectConfig(ctx, config)
+}
+
+// ParsePoolConfig is like pgxpool.ParseConfig but the returned configuration
+// is set up to open connections with the wasip1 package.
+func ParsePoolConfig(connString string) (*pgxpool.Config, error) {
+	config, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	Configure(&config.ConnConfig.Config)
+	return config, nil
+}
+
+// NewPool creates a pool of connections to the postgres server described by
+// connString, see pgxpool.New for details.
+func NewPool(ctx context.Context, connString string) (*pgxpool.Pool, error) {
+	config, err := ParsePoolConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	return pgxpool.NewWithConfig(ctx, config)
+}
+
+// Driver is the database/sql driver registered by this package.
+type Driver struct{}
+
+// Open opens a new connection to the database, name is a connection string.
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	c, err := d.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(context.Background())
+}
+
+// OpenConnector parses the connection string and returns a connector which
+// can be used with sql.OpenDB.
+func (d *Driver) OpenConnector(name string) (driver.Connector, error) {
+	config, err := ParseConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	return &connector{Connector: stdlib.GetConnector(*config), driver: d}, nil
+}
+
+type connector struct {
+	driver.Connector
+	driver *Driver
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+var (
+	_ driver.Driver        = (*Driver)(nil)
+	_ driver.DriverContext = (*Driver)(nil)
+)
@@ -0,0 +1,13 @@
+module github.com/stealthrocket/net/pq
+
+go 1.21
+
+require (
+	github.com/lib/pq v1.11.2
+	github.com/stealthrocket/net v0.3.0
+)
+
+require (
+	golang.org/x/net v0.23.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
@@ -0,0 +1,75 @@
+//go:build wasip1
+
+package pq
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stealthrocket/net/wasip1"
+)
+
+// DriverName is the name of the database/sql driver registered by this
+// package.
+const DriverName = "postgres-wasip1"
+
+func init() {
+	sql.Register(DriverName, new(Driver))
+}
+
+// Driver is the database/sql driver registered by this package.
+type Driver struct{}
+
+// Open opens a new connection to the database, name is a data source name in
+// one of the formats supported by lib/pq.
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	return pq.DialOpen(new(wasip1.Dialer), name)
+}
+
+// OpenConnector parses the data source name and returns a connector which can
+// be used with sql.OpenDB.
+func (d *Driver) OpenConnector(name string) (driver.Connector, error) {
+	c, err := NewConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return &connector{Connector: c, driver: d}, nil
+}
+
+type connector struct {
+	*pq.Connector
+	driver *Driver
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// NewConnector is like pq.NewConnector but the returned connector opens
+// network connections with the wasip1 package.
+func NewConnector(dsn string) (*pq.Connector, error) {
+	c, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	c.Dialer(new(wasip1.Dialer))
+	return c, nil
+}
+
+// NewListener is like pq.NewListener but the listener opens network
+// connections with the wasip1 package.
+func NewListener(name string,
+	minReconnectInterval time.Duration,
+	maxReconnectInterval time.Duration,
+	eventCallback pq.EventCallbackType) *pq.Listener {
+	return pq.NewDialListener(new(wasip1.Dialer), name, minReconnectInterval, maxReconnectInterval, eventCallback)
+}
+
+var (
+	_ driver.Driver        = (*Driver)(nil)
+	_ driver.DriverContext = (*Driver)(nil)
+	_ pq.Dialer            = (*wasip1.Dialer)(nil)
+	_ pq.DialerContext     = (*wasip1.Dialer)(nil)
+)
@@ -4,10 +4,12 @@ go 1.21
 
 require (
 	github.com/redis/go-redis/v9 v9.0.5
-	github.com/stealthrocket/net v0.1.2
+	github.com/stealthrocket/net v0.3.0
 )
 
 require (
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	golang.org/x/net v0.23.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
 )
@@ -1,8 +1,21 @@
-// Package redis provides an example of a Redis client compiled to GOOS=wasip1.
+// Package redis configures clients of the github.com/redis/go-redis/v9 package
+// to open network connections when compiled to GOOS=wasip1.
 //
-// The test uses https://pkg.go.dev/github.com/redis/go-redis/v9 and interacts
-// with a redis server on localhost:6379. The docker-compose.yml file in the
-// parent directory may be used to start a redis server to run the test against.
+// The package provides replacements for the constructors of go-redis, which
+// install a dial function using the github.com/stealthrocket/net/wasip1
+// package when the options do not set one:
+//
+//	client := redis.NewClient(&redis.Options{
+//		Addr: "localhost:6379",
+//	})
+//
+// Cluster, sentinel, and ring clients propagate the dial function to the
+// clients of all the nodes that they discover, including those used for
+// pub/sub, so the connections to all nodes are established with the socket
+// extensions.
+//
+// The package is distributed as a separate module so only applications which
+// use redis need to take a dependency on this module.
 //
 // When compiling to other targets than GOOS=wasip1, importing this package has
 // no effect.
@@ -1,3 +1,85 @@
 //go:build wasip1
 
 package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stealthrocket/net/wasip1"
+)
+
+// NewDialer is like redis.NewDialer but the returned function establishes
+// connections with the wasip1 package.
+//
+// When the options have a TLS configuration which does not set RootCAs, the
+// certificates returned by wasip1.SystemCertPool are used.
+func NewDialer(opt *redis.Options) func(context.Context, string, string) (net.Conn, error) {
+	return newDialer(opt.DialTimeout, opt.TLSConfig)
+}
+
+func newDialer(timeout time.Duration, config *tls.Config) func(context.Context, string, string) (net.Conn, error) {
+	if timeout == 0 {
+		// Same default as the go-redis package.
+		timeout = 5 * time.Second
+	}
+	dialer := &wasip1.Dialer{Timeout: timeout}
+	if config == nil {
+		return dialer.DialContext
+	}
+	tlsDialer := &wasip1.TLSDialer{NetDialer: dialer, Config: config}
+	return tlsDialer.DialContext
+}
+
+// NewClient is like redis.NewClient but the client establishes connections
+// with the wasip1 package if opt.Dialer is nil.
+//
+// The options are copied, opt is not modified.
+func NewClient(opt *redis.Options) *redis.Client {
+	o := *opt
+	if o.Dialer == nil {
+		o.Dialer = NewDialer(&o)
+	}
+	return redis.NewClient(&o)
+}
+
+// NewClusterClient is like redis.NewClusterClient but the client establishes
+// connections to all the nodes of the cluster with the wasip1 package if
+// opt.Dialer is nil.
+//
+// The options are copied, opt is not modified.
+func NewClusterClient(opt *redis.ClusterOptions) *redis.ClusterClient {
+	o := *opt
+	if o.Dialer == nil {
+		o.Dialer = newDialer(o.DialTimeout, o.TLSConfig)
+	}
+	return redis.NewClusterClient(&o)
+}
+
+// NewFailoverClient is like redis.NewFailoverClient but the client establishes
+// connections to the sentinels and to the master with the wasip1 package if
+// opt.Dialer is nil.
+//
+// The options are copied, opt is not modified.
+func NewFailoverClient(opt *redis.FailoverOptions) *redis.Client {
+	o := *opt
+	if o.Dialer == nil {
+		o.Dialer = newDialer(o.DialTimeout, o.TLSConfig)
+	}
+	return redis.NewFailoverClient(&o)
+}
+
+// NewRing is like redis.NewRing but the client establishes connections to all
+// the shards with the wasip1 package if opt.Dialer is nil.
+//
+// The options are copied, opt is not modified.
+func NewRing(opt *redis.RingOptions) *redis.Ring {
+	o := *opt
+	if o.Dialer == nil {
+		o.Dialer = newDialer(o.DialTimeout, o.TLSConfig)
+	}
+	return redis.NewRing(&o)
+}
@@ -7,23 +7,16 @@ import (
 	"testing"
 
 	"github.com/redis/go-redis/v9"
-	"github.com/stealthrocket/net/wasip1"
+	wasiredis "github.com/stealthrocket/net/redis"
 )
 
 func TestRedis(t *testing.T) {
-	client := redis.NewClient(&redis.Options{
-		Addr:   "localhost:6379",
-		Dialer: wasip1.DialContext,
+	client := wasiredis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
 	})
 	defer client.Close()
 
-	ctx := context.Background()
-	if deadline, ok := t.Deadline(); ok {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithDeadline(ctx, deadline)
-		defer cancel()
-	}
-
+	ctx := testContext(t)
 	echo, err := client.Echo(ctx, "Hello, World!\n").Result()
 	if err != nil {
 		t.Fatal(err)
@@ -32,3 +25,59 @@ func TestRedis(t *testing.T) {
 		t.Errorf("wrong echo message received: %q", echo)
 	}
 }
+
+func TestPubSub(t *testing.T) {
+	client := wasiredis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	defer client.Close()
+
+	ctx := testContext(t)
+	pubsub := client.Subscribe(ctx, "wasip1")
+	defer pubsub.Close()
+
+	// Wait for the confirmation of the subscription before publishing.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Publish(ctx, "wasip1", "hello").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := pubsub.ReceiveMessage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Channel != "wasip1" || msg.Payload != "hello" {
+		t.Errorf("wrong message received: %+v", msg)
+	}
+}
+
+func TestRing(t *testing.T) {
+	ring := wasiredis.NewRing(&redis.RingOptions{
+		Addrs: map[string]string{"shard": "localhost:6379"},
+	})
+	defer ring.Close()
+
+	ctx := testContext(t)
+	if err := ring.Set(ctx, "wasip1", "hello", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	value, err := ring.Get(ctx, "wasip1").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "hello" {
+		t.Errorf("wrong value received: %q", value)
+	}
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx := context.Background()
+	if deadline, ok := t.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		t.Cleanup(cancel)
+	}
+	return ctx
+}
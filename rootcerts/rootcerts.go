@@ -0,0 +1,44 @@
+// Package rootcerts embeds the Mozilla CA certificate store, and installs it as
+// the fallback roots of the crypto/x509 package when imported.
+//
+// Programs compiled to GOOS=wasip1 do not have access to the certificates of
+// the host unless the runtime preopens the directory where they are stored and
+// passes the SSL_CERT_FILE or SSL_CERT_DIR environment variables, which often
+// causes TLS clients to fail verifying server certificates. Importing this
+// package makes the embedded certificates available to all TLS clients of the
+// program when the system has no roots:
+//
+//	import (
+//		_ "github.com/stealthrocket/net/rootcerts"
+//	)
+//
+// The certificates are only used when the system roots are empty, and the
+// package must not be imported with other packages calling
+// x509.SetFallbackRoots, such as golang.org/x/crypto/x509roots/fallback.
+package rootcerts
+
+import (
+	"crypto/x509"
+	_ "embed"
+)
+
+//go:embed cacert.pem
+var bundle []byte
+
+var roots = x509.NewCertPool()
+
+func init() {
+	roots.AppendCertsFromPEM(bundle)
+	x509.SetFallbackRoots(roots)
+}
+
+// PEM returns the embedded certificates in PEM format.
+func PEM() []byte {
+	return append([]byte(nil), bundle...)
+}
+
+// CertPool returns a pool containing the embedded certificates, which can be
+// used to configure the RootCAs of a tls.Config explicitly.
+func CertPool() *x509.CertPool {
+	return roots.Clone()
+}
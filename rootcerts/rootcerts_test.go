@@ -0,0 +1,42 @@
+package rootcerts_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stealthrocket/net/rootcerts"
+)
+
+func TestCertPool(t *testing.T) {
+	pool := rootcerts.CertPool()
+	if pool.Equal(x509.NewCertPool()) {
+		t.Fatal("the certificate pool is empty")
+	}
+
+	// Adding a certificate which is already in the pool must not change it.
+	cert := findCertificate(t, "ISRG Root X1")
+	withCert := pool.Clone()
+	withCert.AddCert(cert)
+	if !pool.Equal(withCert) {
+		t.Error("the certificate pool does not contain ISRG Root X1")
+	}
+}
+
+func findCertificate(t *testing.T, commonName string) *x509.Certificate {
+	data := rootcerts.PEM()
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			t.Fatalf("certificate not found: %s", commonName)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cert.Subject.CommonName == commonName {
+			return cert
+		}
+	}
+}
@@ -0,0 +1,107 @@
+//go:build wasip1
+
+package wasip1
+
+import (
+	"io"
+	"net"
+	"os"
+	"syscall"
+
+	wasisyscall "github.com/stealthrocket/net/wasip1/syscall"
+)
+
+// The net package uses vectored writes in net.Buffers.WriteTo when the writer
+// implements an unexported interface, which only the connections of the net
+// package implement, and only on unix and windows. On GOOS=wasip1, v.WriteTo
+// writes the buffers one at a time, and there is no way to change it from
+// outside the net package. Instead, TCPConn and UnixConn have WriteBuffers and
+// ReadBuffers methods, and the WriteBuffers function must be called in place
+// of net.Buffers.WriteTo, to write or read multiple buffers with a single call
+// to sock_send or sock_recv.
+
+// maxIovecs is the maximum number of buffers passed to the host in a single
+// call to sock_send or sock_recv, larger vectors are truncated.
+const maxIovecs = 64
+
+// WriteBuffers writes the contents of v to w and consumes the buffers, like
+// v.WriteTo(w), but uses vectored writes when w is a TCPConn, a UnixConn, or
+// a *net.TCPConn such as the connections returned by Dial or accepted from a
+// listener created by Listen.
+//
+// Calling v.WriteTo(w) directly does not use vectored writes on GOOS=wasip1,
+// even when w is one of these connections, since the net package does not
+// support it on this platform.
+func WriteBuffers(w io.Writer, v *net.Buffers) (int64, error) {
+	switch c := w.(type) {
+	case buffersWriter:
+		return c.WriteBuffers(v)
+	case *net.TCPConn:
+		return (&TCPConn{c}).WriteBuffers(v)
+	default:
+		return v.WriteTo(w)
+	}
+}
+
+type buffersWriter interface {
+	WriteBuffers(*net.Buffers) (int64, error)
+}
+
+func writeBuffers(rawConn syscall.RawConn, v *net.Buffers) (n int64, err error) {
+	for len(*v) > 0 && err == nil {
+		var nw int
+		rawConnErr := rawConn.Write(func(fd uintptr) bool {
+			nw, err = wasisyscall.SendBuffers(int(fd), (*v)[:min(len(*v), maxIovecs)], 0)
+			return err != syscall.EAGAIN
+		})
+		if rawConnErr != nil {
+			err = rawConnErr
+		} else if err != nil {
+			err = os.NewSyscallError("sock_send", err)
+		} else if nw == 0 && !emptyBuffers(*v) {
+			err = io.ErrShortWrite
+		}
+		n += int64(nw)
+		consumeBuffers(v, int64(nw))
+	}
+	return n, err
+}
+
+func readBuffers(rawConn syscall.RawConn, bufs [][]byte) (n int, err error) {
+	rawConnErr := rawConn.Read(func(fd uintptr) bool {
+		n, _, err = wasisyscall.RecvBuffers(int(fd), bufs[:min(len(bufs), maxIovecs)], 0)
+		return err != syscall.EAGAIN
+	})
+	if rawConnErr != nil {
+		err = rawConnErr
+	} else if err != nil {
+		err = os.NewSyscallError("sock_recv", err)
+	} else if n == 0 && !emptyBuffers(bufs) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// consumeBuffers removes n bytes from the front of v, it is the equivalent of
+// the unexported net.Buffers.consume method.
+func consumeBuffers(v *net.Buffers, n int64) {
+	for len(*v) > 0 {
+		ln0 := int64(len((*v)[0]))
+		if ln0 > n {
+			(*v)[0] = (*v)[0][n:]
+			return
+		}
+		n -= ln0
+		(*v)[0] = nil
+		*v = (*v)[1:]
+	}
+}
+
+func emptyBuffers(bufs [][]byte) bool {
+	for _, b := range bufs {
+		if len(b) != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+//go:build wasip1
+
+package wasip1
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Locations of the certificate bundles of common Linux distributions and
+// macOS, which are accessible when the runtime preopens the root directory of
+// the host.
+var certFiles = []string{
+	"/etc/ssl/certs/ca-certificates.crt",                // Debian/Ubuntu/Gentoo etc.
+	"/etc/pki/tls/certs/ca-bundle.crt",                  // Fedora/RHEL 6
+	"/etc/ssl/ca-bundle.pem",                            // OpenSUSE
+	"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem", // CentOS/RHEL 7
+	"/etc/ssl/cert.pem",                                 // Alpine Linux, macOS
+}
+
+// LoadCertPool returns a certificate pool containing the PEM encoded
+// certificates found at path, which may be a file or a directory. The path
+// must be within a directory preopened by the WebAssembly runtime.
+//
+// When path is a directory, the certificates of all the regular files that it
+// contains are loaded; files which do not contain certificates are ignored.
+// An error is returned if no certificates were found.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		if err := appendCertsFromFile(pool, path); err != nil {
+			return nil, err
+		}
+	} else {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			// Errors are ignored, directories of certificates often contain
+			// files in other formats or broken links.
+			_ = appendCertsFromFile(pool, filepath.Join(path, entry.Name()))
+		}
+	}
+	if pool.Equal(x509.NewCertPool()) {
+		return nil, fmt.Errorf("%s: no certificates found", path)
+	}
+	return pool, nil
+}
+
+func appendCertsFromFile(pool *x509.CertPool, path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !pool.AppendCertsFromPEM(b) {
+		return fmt.Errorf("%s: no certificates found", path)
+	}
+	return nil
+}
+
+// SystemCertPool returns the pool of root certificates used by the TLS helpers
+// of this package when the configuration does not set RootCAs.
+//
+// The certificates are loaded from the paths set in the SSL_CERT_FILE and
+// SSL_CERT_DIR environment variables if any, otherwise from the well-known
+// locations of the certificate bundles of common operating systems if the
+// runtime preopened them. When no certificates are found, the function
+// returns the result of x509.SystemCertPool, which includes the fallback roots
+// installed by importing the github.com/stealthrocket/net/rootcerts package.
+//
+// The certificates are loaded once, on the first call; the returned pool is a
+// copy which may be modified by the caller.
+func SystemCertPool() (*x509.CertPool, error) {
+	pool, err := systemCertPool()
+	if err != nil {
+		return nil, err
+	}
+	return pool.Clone(), nil
+}
+
+var systemCertPool = sync.OnceValues(func() (*x509.CertPool, error) {
+	if os.Getenv("SSL_CERT_FILE") == "" && os.Getenv("SSL_CERT_DIR") == "" {
+		for _, path := range certFiles {
+			if pool, err := LoadCertPool(path); err == nil {
+				return pool, nil
+			}
+		}
+	}
+	// The crypto/x509 package reads SSL_CERT_FILE and SSL_CERT_DIR, and uses
+	// the fallback roots when the system has none.
+	return x509.SystemCertPool()
+})
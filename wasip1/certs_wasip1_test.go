@@ -0,0 +1,71 @@
+//go:build wasip1
+
+package wasip1_test
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stealthrocket/net/internal/testcert"
+	"github.com/stealthrocket/net/wasip1"
+)
+
+func TestLoadCertPool(t *testing.T) {
+	cert, roots, err := testcert.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cert.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Files which do not contain certificates must be ignored when loading
+	// a directory.
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{path, dir} {
+		pool, err := wasip1.LoadCertPool(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pool.Equal(roots) {
+			t.Errorf("%s: wrong certificates loaded", path)
+		}
+	}
+
+	if _, err := wasip1.LoadCertPool(t.TempDir()); err == nil {
+		t.Error("loading an empty directory did not return an error")
+	}
+	if _, err := wasip1.LoadCertPool(filepath.Join(dir, "missing.pem")); err == nil {
+		t.Error("loading a missing file did not return an error")
+	}
+
+	l, err := wasip1.ListenTLS("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		c.(*tls.Conn).Handshake()
+	}()
+
+	// The certificate is not in the system roots, the handshake must fail.
+	if _, err := wasip1.DialTLS("tcp", l.Addr().String(), nil); err == nil {
+		t.Fatal("the server certificate was not verified")
+	}
+}
@@ -6,10 +6,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"net"
+	"net/netip"
 	"os"
+	"path/filepath"
 	"syscall"
 	"time"
+
+	wasisyscall "github.com/stealthrocket/net/wasip1/syscall"
 )
 
 func init() {
@@ -52,9 +57,6 @@ func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.
 		ctx, cancel = context.WithTimeout(ctx, timeout)
 		defer cancel()
 	}
-	if d.LocalAddr != nil {
-		println("wasip1.Dialer: LocalAddr not yet supported on GOOS=wasip1")
-	}
 	if d.Resolver != nil {
 		println("wasip1.Dialer: Resolver ignored because it is not supported on GOOS=wasip1")
 	}
@@ -68,11 +70,10 @@ func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.
 		println("wasip1.Dialer: ControlContext function not yet supported on GOOS=wasip1")
 	}
 	// TOOD:
-	// - use LocalAddr to bind to a socket prior to establishing the connection
 	// - use DualStack and FallbackDelay
 	// - use Control and ControlContext functions
 	// - emulate the Cancel channel with context.Context
-	return DialContext(ctx, network, address)
+	return dialContext(ctx, d.LocalAddr, network, address)
 }
 
 // DialTimeout is not present in net.Dialer but this type provides it because it
@@ -91,6 +92,10 @@ func Dial(network, address string) (net.Conn, error) {
 
 // DialContext is a variant of Dial that accepts a context.
 func DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return dialContext(ctx, nil, network, address)
+}
+
+func dialContext(ctx context.Context, laddr net.Addr, network, address string) (net.Conn, error) {
 	addrs, err := lookupAddr(ctx, "dial", network, address)
 	if err != nil {
 		addr := &netAddr{network, address}
@@ -99,7 +104,7 @@ func DialContext(ctx context.Context, network, address string) (net.Conn, error)
 	var addr net.Addr
 	var conn net.Conn
 	for _, addr = range addrs {
-		conn, err = dialAddr(ctx, addr)
+		conn, err = dialAddr(ctx, laddr, addr)
 		if err == nil {
 			return conn, nil
 		}
@@ -110,17 +115,68 @@ func DialContext(ctx context.Context, network, address string) (net.Conn, error)
 	return nil, dialErr(addr, err)
 }
 
+// DialAddrPort connects to the address on the named network, which must be one
+// of "tcp", "tcp4", "tcp6", "udp", "udp4", or "udp6".
+//
+// Unlike DialContext, the function does not need to resolve or parse the
+// address, which avoids the associated costs when the program already has a
+// netip.AddrPort.
+func DialAddrPort(ctx context.Context, network string, addr netip.AddrPort) (net.Conn, error) {
+	var sotype int
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		sotype = SOCK_STREAM
+	case "udp", "udp4", "udp6":
+		sotype = SOCK_DGRAM
+	default:
+		return nil, unsupportedNetwork(network, addr.String())
+	}
+	connectAddr, err := addrPortSockaddr(network, addr)
+	if err != nil {
+		return nil, dialErr(addrPortNetAddr(network, addr), err)
+	}
+	c, err := dialSockaddr(ctx, sotype, nil, connectAddr)
+	if err != nil {
+		return nil, dialErr(addrPortNetAddr(network, addr), err)
+	}
+	return c, nil
+}
+
+func addrPortNetAddr(network string, addr netip.AddrPort) net.Addr {
+	switch network {
+	case "udp", "udp4", "udp6":
+		return net.UDPAddrFromAddrPort(addr)
+	default:
+		return net.TCPAddrFromAddrPort(addr)
+	}
+}
+
 func dialErr(addr net.Addr, err error) error {
 	return newOpError("dial", addr, err)
 }
 
-func dialAddr(ctx context.Context, addr net.Addr) (net.Conn, error) {
-	proto := family(addr)
+func dialAddr(ctx context.Context, laddr, addr net.Addr) (net.Conn, error) {
 	sotype, err := socketType(addr)
 	if err != nil {
 		return nil, os.NewSyscallError("socket", err)
 	}
-	fd, err := socket(proto, sotype, 0)
+	var bindAddr wasisyscall.Sockaddr
+	if laddr != nil {
+		bindAddr, err = socketAddress(laddr)
+		if err != nil {
+			return nil, os.NewSyscallError("bind", err)
+		}
+	}
+	connectAddr, err := socketAddress(addr)
+	if err != nil {
+		return nil, os.NewSyscallError("sockaddr", err)
+	}
+	return dialSockaddr(ctx, sotype, bindAddr, connectAddr)
+}
+
+func dialSockaddr(ctx context.Context, sotype int, bindAddr, connectAddr wasisyscall.Sockaddr) (net.Conn, error) {
+	proto := sockaddrFamily(connectAddr)
+	fd, err := wasisyscall.Socket(proto, sotype, 0)
 	if err != nil {
 		return nil, os.NewSyscallError("socket", err)
 	}
@@ -134,7 +190,7 @@ func dialAddr(ctx context.Context, addr net.Addr) (net.Conn, error) {
 		return nil, err
 	}
 	if sotype == SOCK_DGRAM && proto != AF_UNIX {
-		if err := setsockopt(fd, SOL_SOCKET, SO_BROADCAST, 1); err != nil {
+		if err := wasisyscall.SetsockoptInt(fd, SOL_SOCKET, SO_BROADCAST, 1); err != nil {
 			// If the system does not support broadcast we should still be able
 			// to use the datagram socket.
 			switch {
@@ -146,12 +202,27 @@ func dialAddr(ctx context.Context, addr net.Addr) (net.Conn, error) {
 		}
 	}
 
-	connectAddr, err := socketAddress(addr)
-	if err != nil {
-		return nil, os.NewSyscallError("sockaddr", err)
+	// Datagram unix sockets must be bound to a path for the peer to be able to
+	// send responses. When no local address was given, we automatically bind
+	// the socket to a temporary path which is removed when the socket is
+	// closed.
+	var unlink string
+	switch {
+	case bindAddr != nil:
+		if err := wasisyscall.Bind(fd, bindAddr); err != nil {
+			return nil, os.NewSyscallError("bind", err)
+		}
+	case sotype == SOCK_DGRAM && proto == AF_UNIX:
+		unlink = autobind(fd)
 	}
+	defer func() {
+		if unlink != "" && fd >= 0 {
+			os.Remove(unlink)
+		}
+	}()
+
 	var inProgress bool
-	switch err := connect(fd, connectAddr); err {
+	switch err := wasisyscall.Connect(fd, connectAddr); err {
 	case nil:
 	case syscall.EINPROGRESS:
 		inProgress = true
@@ -160,17 +231,22 @@ func dialAddr(ctx context.Context, addr net.Addr) (net.Conn, error) {
 	}
 
 	if sotype == SOCK_DGRAM {
-		name, err := getsockname(fd)
+		name, err := wasisyscall.Getsockname(fd)
 		if err != nil {
 			return nil, err
 		}
-		peer, err := getpeername(fd)
+		peer, err := wasisyscall.Getpeername(fd)
 		if err != nil {
 			return nil, err
 		}
 		f := os.NewFile(uintptr(fd), "")
 		fd = -1
-		return makePacketConn(f, name, peer), nil
+		c := makePacketConn(f, name, peer)
+		c.unlink = unlink
+		if _, unix := name.(*wasisyscall.SockaddrUnix); unix {
+			return newUnixPacketConn(c), nil
+		}
+		return &UDPConn{c}, nil
 	}
 
 	f := os.NewFile(uintptr(fd), "")
@@ -178,55 +254,8 @@ func dialAddr(ctx context.Context, addr net.Addr) (net.Conn, error) {
 	defer f.Close()
 
 	if inProgress {
-		rawConn, err := f.SyscallConn()
-		if err != nil {
-			return nil, fmt.Errorf("os.(*File).SyscallConn: %w", err)
-		}
-
-		errch := make(chan error)
-		go func() {
-			var err error
-			rawConnErr := rawConn.Write(func(fd uintptr) bool {
-				var value int
-				value, err = getsockopt(int(fd), SOL_SOCKET, SO_ERROR)
-				if err != nil {
-					return true // done
-				}
-				switch syscall.Errno(value) {
-				case syscall.EINPROGRESS, syscall.EINTR:
-					return false // continue
-				case syscall.EISCONN:
-					err = nil
-					return true
-				case syscall.Errno(0):
-					// The net poller can wake up spuriously. Check that we are
-					// are really connected.
-					_, err := getpeername(int(fd))
-					return err == nil
-				default:
-					err = syscall.Errno(value)
-					return true
-				}
-			})
-			if err == nil {
-				err = rawConnErr
-			}
-			errch <- err
-		}()
-
-		select {
-		case err := <-errch:
-			if err != nil {
-				return nil, os.NewSyscallError("connect", err)
-			}
-		case <-ctx.Done():
-			// This should interrupt the async connect operation handled by the
-			// goroutine.
-			f.Close()
-			// Wait for the goroutine to complete, we can safely discard the
-			// error here because we don't care about the socket anymore.
-			<-errch
-			return nil, context.Cause(ctx)
+		if err := waitConnect(ctx, f); err != nil {
+			return nil, err
 		}
 	}
 
@@ -236,3 +265,88 @@ func dialAddr(ctx context.Context, addr net.Addr) (net.Conn, error) {
 	}
 	return makeConn(c)
 }
+
+// aLongTimeAgo is a non-zero time in the past, used to interrupt blocking
+// operations by setting deadlines.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// waitConnect blocks the calling goroutine until the asynchronous connection
+// of f completes, or the context is done.
+//
+// The wait is bounded by the write deadline of f: it is initialized from the
+// context deadline, and moved to the past when the context is canceled, which
+// wakes up the net poller.
+func waitConnect(ctx context.Context, f *os.File) error {
+	rawConn, err := f.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("os.(*File).SyscallConn: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := f.SetWriteDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	stop := context.AfterFunc(ctx, func() {
+		f.SetWriteDeadline(aLongTimeAgo)
+	})
+
+	rawConnErr := rawConn.Write(func(fd uintptr) bool {
+		var value int
+		value, err = wasisyscall.GetsockoptInt(int(fd), SOL_SOCKET, SO_ERROR)
+		if err != nil {
+			return true // done
+		}
+		switch syscall.Errno(value) {
+		case syscall.EINPROGRESS, syscall.EINTR:
+			return false // continue
+		case syscall.EISCONN:
+			err = nil
+			return true
+		case syscall.Errno(0):
+			// The net poller can wake up spuriously. Check that we are
+			// really connected.
+			_, err = wasisyscall.Getpeername(int(fd))
+			return err == nil
+		default:
+			err = syscall.Errno(value)
+			return true
+		}
+	})
+
+	if !stop() {
+		// The context was canceled, the function may still be running and
+		// change the deadline concurrently, but the file is about to be closed
+		// so it does not matter.
+		return context.Cause(ctx)
+	}
+	if rawConnErr != nil {
+		if errors.Is(rawConnErr, os.ErrDeadlineExceeded) {
+			// The net poller may observe the deadline slightly before the
+			// context timer fires.
+			return context.DeadlineExceeded
+		}
+		return rawConnErr
+	}
+	if err != nil {
+		return os.NewSyscallError("connect", err)
+	}
+	return f.SetWriteDeadline(time.Time{})
+}
+
+// autobind binds the unix socket to a randomly generated path in the temporary
+// directory, returning the path or an empty string if the socket could not be
+// bound, which may happen if the temporary directory was not preopened.
+func autobind(fd int) string {
+	dir := os.TempDir()
+	for i := 0; i < 10; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("wasip1-%08x.sock", rand.Uint32()))
+		switch err := wasisyscall.Bind(fd, &wasisyscall.SockaddrUnix{Name: name}); err {
+		case nil:
+			return name
+		case syscall.EADDRINUSE:
+		default:
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+//go:build wasip1
+
+package wasip1
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+
+	wasisyscall "github.com/stealthrocket/net/wasip1/syscall"
+)
+
+// FileConn returns a copy of the network connection corresponding to the open
+// file f. It is the caller's responsibility to close f when finished. Closing
+// c does not affect f, and closing f does not affect c.
+//
+// Unlike net.FileConn, the local and remote addresses of the connection are
+// initialized, unix sockets are returned as *UnixConn, and datagram sockets as
+// *UDPConn or *UnixConn.
+func FileConn(f *os.File) (net.Conn, error) {
+	c, err := net.FileConn(f)
+	if err != nil {
+		return nil, fileErr(f, err)
+	}
+	if uc, ok := c.(*net.UDPConn); ok {
+		return makeFilePacketConn(uc)
+	}
+	return makeConn(c)
+}
+
+// FileListener returns a copy of the network listener corresponding to the
+// open file f. It is the caller's responsibility to close the listener when
+// finished. Closing the listener does not affect f, and closing f does not
+// affect the listener.
+//
+// Unlike net.FileListener, the address of the listener is initialized, and
+// unix sockets are returned as *UnixListener.
+func FileListener(f *os.File) (net.Listener, error) {
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, fileErr(f, err)
+	}
+	rawConn, err := l.(syscall.Conn).SyscallConn()
+	if err != nil {
+		l.Close()
+		return nil, fmt.Errorf("syscall.Conn.SyscallConn: %w", err)
+	}
+	var name wasisyscall.Sockaddr
+	rawConnErr := rawConn.Control(func(fd uintptr) {
+		if name, err = wasisyscall.Getsockname(int(fd)); err != nil {
+			err = os.NewSyscallError("getsockname", err)
+		}
+	})
+	if err == nil {
+		err = rawConnErr
+	}
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	return makeListener(l, name), nil
+}
+
+// FilePacketConn returns a copy of the packet network connection corresponding
+// to the open file f. It is the caller's responsibility to close f when
+// finished. Closing c does not affect f, and closing f does not affect c.
+//
+// The standard library does not support creating packet connections from
+// files on GOOS=wasip1, this function returns a *UDPConn or *UnixConn.
+func FilePacketConn(f *os.File) (net.PacketConn, error) {
+	c, err := net.FileConn(f)
+	if err != nil {
+		return nil, fileErr(f, err)
+	}
+	uc, ok := c.(*net.UDPConn)
+	if !ok {
+		c.Close()
+		return nil, fileErr(f, syscall.EPROTOTYPE)
+	}
+	return makeFilePacketConn(uc)
+}
+
+// makeFilePacketConn wraps c, a copy of the socket created by net.FileConn, so
+// it can be used as a packet connection. The *net.UDPConn type is not used directly
+// because its packet methods are not implemented on GOOS=wasip1.
+func makeFilePacketConn(c *net.UDPConn) (datagramConn, error) {
+	rawConn, err := c.SyscallConn()
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("net.(*UDPConn).SyscallConn: %w", err)
+	}
+	var name, peer wasisyscall.Sockaddr
+	rawConnErr := rawConn.Control(func(fd uintptr) {
+		if name, err = wasisyscall.Getsockname(int(fd)); err != nil {
+			err = os.NewSyscallError("getsockname", err)
+			return
+		}
+		// The socket may not be connected, in which case the remote address
+		// is left empty.
+		peer, _ = wasisyscall.Getpeername(int(fd))
+	})
+	if err == nil {
+		err = rawConnErr
+	}
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	pc := makePacketConn(c, name, peer)
+	if _, unix := name.(*wasisyscall.SockaddrUnix); unix {
+		return newUnixPacketConn(pc), nil
+	}
+	return &UDPConn{pc}, nil
+}
+
+// datagramConn is implemented by both *UDPConn and *UnixConn.
+type datagramConn interface {
+	net.Conn
+	net.PacketConn
+}
+
+func fileErr(f *os.File, err error) error {
+	return &net.OpError{
+		Op:   "file",
+		Net:  "file+net",
+		Addr: fileAddr(f.Name()),
+		Err:  err,
+	}
+}
+
+type fileAddr string
+
+func (fileAddr) Network() string  { return "file+net" }
+func (f fileAddr) String() string { return string(f) }
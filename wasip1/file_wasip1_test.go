@@ -0,0 +1,93 @@
+//go:build wasip1
+
+package wasip1
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	wasisyscall "github.com/stealthrocket/net/wasip1/syscall"
+)
+
+func TestFilePacketConn(t *testing.T) {
+	fd, err := wasisyscall.Socket(AF_INET, SOCK_DGRAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wasisyscall.Bind(fd, &wasisyscall.SockaddrInet4{Addr: [4]byte{127, 0, 0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	f := os.NewFile(uintptr(fd), "udp")
+
+	c, err := FilePacketConn(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	laddr, ok := c.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		t.Fatalf("wrong local address type: %T", c.LocalAddr())
+	}
+	if !laddr.IP.Equal(net.IPv4(127, 0, 0, 1)) || laddr.Port == 0 {
+		t.Fatalf("local address was not initialized: %s", laddr)
+	}
+
+	// The connection must remain usable after closing the file.
+	u, err := DialUDP("udp", nil, laddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer u.Close()
+
+	if _, err := u.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 32)
+	n, addr, err := c.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "hello" || addr.String() != u.LocalAddr().String() {
+		t.Fatalf("wrong datagram received from %s: %q", addr, b[:n])
+	}
+}
+
+func TestFileListener(t *testing.T) {
+	fd, err := wasisyscall.Socket(AF_INET, SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wasisyscall.Bind(fd, &wasisyscall.SockaddrInet4{Addr: [4]byte{127, 0, 0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wasisyscall.Listen(fd, 1); err != nil {
+		t.Fatal(err)
+	}
+	f := os.NewFile(uintptr(fd), "tcp")
+
+	l, err := FileListener(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	c, err := Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	a, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	if a.RemoteAddr().String() != c.LocalAddr().String() {
+		t.Fatalf("address mismatch: %s != %s", a.RemoteAddr(), c.LocalAddr())
+	}
+}
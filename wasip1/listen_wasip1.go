@@ -4,28 +4,60 @@ package wasip1
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/netip"
 	"os"
+	"sync"
 	"syscall"
 	"time"
+
+	wasisyscall "github.com/stealthrocket/net/wasip1/syscall"
 )
 
+// ListenConfig is a type similar to net.ListenConfig but it uses the socket
+// functions defined in this package instead of those from the standard library.
+//
+// The zero-value is a valid configuration equivalent to calling Listen.
+type ListenConfig struct {
+	// RemoveStaleUnixSocket instructs Listen to remove the socket file when
+	// binding a unix socket fails because the path already exists but no
+	// process is accepting connections on it, which usually happens when a
+	// previous instance of the program exited without closing its listener.
+	//
+	// The state of the socket is probed with a connection attempt, which a
+	// live server observes as a connection closed without data. The probe
+	// races with servers starting up concurrently on the same path.
+	RemoveStaleUnixSocket bool
+
+	// UnixSocketMode, when non-zero, sets the permissions of the socket file
+	// created when listening on a unix socket. Note that the WebAssembly
+	// runtime may not support changing file permissions, in which case Listen
+	// returns an error.
+	UnixSocketMode os.FileMode
+}
+
 // Listen announces on the local network address.
 func Listen(network, address string) (net.Listener, error) {
+	var lc ListenConfig
+	return lc.Listen(context.Background(), network, address)
+}
+
+// Listen announces on the local network address.
+func (lc *ListenConfig) Listen(ctx context.Context, network, address string) (net.Listener, error) {
 	switch network {
 	case "tcp", "tcp4", "tcp6", "unix":
 	default:
 		return nil, unsupportedNetwork(network, address)
 	}
-	addrs, err := lookupAddr(context.Background(), "listen", network, address)
+	addrs, err := lookupAddr(ctx, "listen", network, address)
 	if err != nil {
 		addr := &netAddr{network, address}
 		return nil, listenErr(addr, err)
 	}
-	lstn, err := listenAddr(addrs[0])
+	lstn, err := lc.listenAddrs(network, addrs)
 	if err != nil {
 		return nil, listenErr(addrs[0], err)
 	}
@@ -34,16 +66,43 @@ func Listen(network, address string) (net.Listener, error) {
 
 // ListenPacket creates a listening packet connection.
 func ListenPacket(network, address string) (net.PacketConn, error) {
+	var lc ListenConfig
+	return lc.ListenPacket(context.Background(), network, address)
+}
+
+// ListenPacket creates a listening packet connection.
+//
+// Unlike Listen, the connection is always backed by a single socket. If the
+// host in the address resolves to multiple IP addresses, ListenPacket only
+// binds the first one.
+//
+// When the network is "udp" and the address has an empty host, the function
+// binds the IPv6 wildcard address, and falls back to the IPv4 wildcard address
+// if the host does not support IPv6. The WASI socket extensions do not define
+// how to configure IPV6_V6ONLY, so the connection only receives IPv4 datagrams
+// on hosts where IPv6 sockets are dual-stack by default (e.g. Linux or macOS).
+// Programs which must receive IPv4 datagrams on other hosts should use the
+// "udp4" network, or open one connection per address family.
+func (lc *ListenConfig) ListenPacket(ctx context.Context, network, address string) (net.PacketConn, error) {
 	switch network {
 	case "udp", "udp4", "udp6", "unixgram":
 	default:
 		return nil, unsupportedNetwork(network, address)
 	}
-	addrs, err := lookupAddr(context.Background(), "listen", network, address)
+	addrs, err := lookupAddr(ctx, "listen", network, address)
 	if err != nil {
 		addr := &netAddr{network, address}
 		return nil, listenErr(addr, err)
 	}
+	if addr, ok := dualStackAddr(network, addrs[0]); ok {
+		conn, err := listenPacketAddr(addr)
+		if err == nil {
+			return conn, nil
+		}
+		if !isAddrNotAvailable(err) {
+			return nil, listenErr(addrs[0], err)
+		}
+	}
 	conn, err := listenPacketAddr(addrs[0])
 	if err != nil {
 		return nil, listenErr(addrs[0], err)
@@ -51,6 +110,50 @@ func ListenPacket(network, address string) (net.PacketConn, error) {
 	return conn, nil
 }
 
+// ListenAddrPort announces on the local address, the network must be one of
+// "tcp", "tcp4", or "tcp6".
+//
+// Unlike Listen, the function does not need to resolve or parse the address.
+func ListenAddrPort(network string, addr netip.AddrPort) (net.Listener, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, unsupportedNetwork(network, addr.String())
+	}
+	bindAddr, err := addrPortSockaddr(network, addr)
+	if err != nil {
+		return nil, listenErr(net.TCPAddrFromAddrPort(addr), err)
+	}
+	var lc ListenConfig
+	l, err := lc.listenSockaddr(bindAddr)
+	if err != nil {
+		return nil, listenErr(net.TCPAddrFromAddrPort(addr), err)
+	}
+	return l, nil
+}
+
+// ListenPacketAddrPort creates a packet connection bound to the local address,
+// the network must be one of "udp", "udp4", or "udp6".
+//
+// Unlike ListenPacket, the function does not need to resolve or parse the
+// address.
+func ListenPacketAddrPort(network string, addr netip.AddrPort) (net.PacketConn, error) {
+	switch network {
+	case "udp", "udp4", "udp6":
+	default:
+		return nil, unsupportedNetwork(network, addr.String())
+	}
+	bindAddr, err := addrPortSockaddr(network, addr)
+	if err != nil {
+		return nil, listenErr(net.UDPAddrFromAddrPort(addr), err)
+	}
+	c, err := listenPacketSockaddr(bindAddr)
+	if err != nil {
+		return nil, listenErr(net.UDPAddrFromAddrPort(addr), err)
+	}
+	return c, nil
+}
+
 func unsupportedNetwork(network, address string) error {
 	return fmt.Errorf("unsupported network: %s://%s", network, address)
 }
@@ -59,8 +162,133 @@ func listenErr(addr net.Addr, err error) error {
 	return newOpError("listen", addr, err)
 }
 
-func listenAddr(addr net.Addr) (net.Listener, error) {
-	fd, err := socket(family(addr), SOCK_STREAM, 0)
+// listenAddrs creates a listener accepting connections on all the addresses.
+//
+// When the network is "tcp" and the address has an empty host, the function
+// listens on the IPv6 wildcard address, which accepts IPv4 connections on
+// hosts where IPv6 sockets are dual-stack by default (e.g. Linux or macOS),
+// and on the IPv4 wildcard address for the other hosts.
+func (lc *ListenConfig) listenAddrs(network string, addrs []net.Addr) (net.Listener, error) {
+	if addr, ok := dualStackAddr(network, addrs[0]); ok {
+		return lc.listenDualStack(addr, addrs[0])
+	}
+	if len(addrs) == 1 {
+		return lc.listenAddr(addrs[0])
+	}
+
+	var firstErr error
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		if len(listeners) > 0 {
+			// When listening on port zero, all listeners must use the port
+			// that was assigned to the first socket.
+			addr = withPort(addr, listeners[0].Addr())
+		}
+		l, err := lc.listenAddr(addr)
+		if err != nil {
+			if isAddrNotAvailable(err) {
+				// The host may not support one of the address families, in
+				// which case it is not an error to only listen on a subset of
+				// the addresses.
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			closeListeners(listeners)
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	switch len(listeners) {
+	case 0:
+		return nil, firstErr
+	case 1:
+		return listeners[0], nil
+	default:
+		return newMultiListener(listeners), nil
+	}
+}
+
+// listenDualStack listens on the IPv6 and IPv4 wildcard addresses.
+//
+// The WASI socket extensions do not define how to configure IPV6_V6ONLY, so
+// whether the IPv6 socket also accepts IPv4 connections depends on the host.
+// When it does, binding the IPv4 wildcard address on the same port fails with
+// EADDRINUSE, which is the only case where this error is not reported: the
+// first socket was successfully bound by this function, so the conflict can
+// only be with a socket owned by another program on a host where IPv6 sockets
+// are not dual-stack, in which case the listener only accepts IPv6
+// connections.
+func (lc *ListenConfig) listenDualStack(ipv6, ipv4 net.Addr) (net.Listener, error) {
+	l6, err := lc.listenAddr(ipv6)
+	if err != nil {
+		if isAddrNotAvailable(err) {
+			// IPv6 is not supported by the host.
+			return lc.listenAddr(ipv4)
+		}
+		return nil, err
+	}
+	l4, err := lc.listenAddr(withPort(ipv4, l6.Addr()))
+	if err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) || isAddrNotAvailable(err) {
+			return l6, nil
+		}
+		l6.Close()
+		return nil, err
+	}
+	return newMultiListener([]net.Listener{l6, l4}), nil
+}
+
+func closeListeners(listeners []net.Listener) {
+	for _, l := range listeners {
+		l.Close()
+	}
+}
+
+// dualStackAddr returns the IPv6 wildcard address to listen on when the network
+// and address describe a wildcard listener for both IPv4 and IPv6.
+func dualStackAddr(network string, addr net.Addr) (net.Addr, bool) {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		if network == "tcp" && a.IP.Equal(net.IPv4zero) {
+			return &net.TCPAddr{IP: net.IPv6zero, Port: a.Port}, true
+		}
+	case *net.UDPAddr:
+		if network == "udp" && a.IP.Equal(net.IPv4zero) {
+			return &net.UDPAddr{IP: net.IPv6zero, Port: a.Port}, true
+		}
+	}
+	return nil, false
+}
+
+func withPort(addr, portAddr net.Addr) net.Addr {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		if p, ok := portAddr.(*net.TCPAddr); ok && a.Port == 0 {
+			return &net.TCPAddr{IP: a.IP, Port: p.Port, Zone: a.Zone}
+		}
+	}
+	return addr
+}
+
+// isAddrNotAvailable returns true if err indicates that the address family is
+// not supported by the host.
+func isAddrNotAvailable(err error) bool {
+	return errors.Is(err, syscall.EADDRNOTAVAIL) ||
+		errors.Is(err, syscall.EAFNOSUPPORT)
+}
+
+func (lc *ListenConfig) listenAddr(addr net.Addr) (net.Listener, error) {
+	bindAddr, err := socketAddress(addr)
+	if err != nil {
+		return nil, os.NewSyscallError("bind", err)
+	}
+	return lc.listenSockaddr(bindAddr)
+}
+
+func (lc *ListenConfig) listenSockaddr(bindAddr wasisyscall.Sockaddr) (net.Listener, error) {
+	fd, err := wasisyscall.Socket(sockaddrFamily(bindAddr), SOCK_STREAM, 0)
 	if err != nil {
 		return nil, os.NewSyscallError("socket", err)
 	}
@@ -77,19 +305,36 @@ func listenAddr(addr net.Addr) (net.Listener, error) {
 		return nil, err
 	}
 
-	bindAddr, err := socketAddress(addr)
-	if err != nil {
-		return nil, os.NewSyscallError("bind", err)
+	if err := wasisyscall.Bind(fd, bindAddr); err != nil {
+		if err != syscall.EADDRINUSE || !lc.RemoveStaleUnixSocket || !removeStaleUnixSocket(bindAddr) {
+			return nil, os.NewSyscallError("bind", err)
+		}
+		if err := wasisyscall.Bind(fd, bindAddr); err != nil {
+			return nil, os.NewSyscallError("bind", err)
+		}
 	}
-	if err := bind(fd, bindAddr); err != nil {
-		return nil, os.NewSyscallError("bind", err)
+	// From this point, the listener owns the socket file and must remove it if
+	// an error occurs.
+	var unlink string
+	if a, ok := bindAddr.(*wasisyscall.SockaddrUnix); ok {
+		unlink = a.Name
+	}
+	defer func() {
+		if unlink != "" && fd >= 0 {
+			os.Remove(unlink)
+		}
+	}()
+	if unlink != "" && lc.UnixSocketMode != 0 {
+		if err := os.Chmod(unlink, lc.UnixSocketMode); err != nil {
+			return nil, err
+		}
 	}
 	const backlog = 64 // TODO: configurable?
-	if err := listen(fd, backlog); err != nil {
+	if err := wasisyscall.Listen(fd, backlog); err != nil {
 		return nil, os.NewSyscallError("listen", err)
 	}
 
-	name, err := getsockname(fd)
+	name, err := wasisyscall.Getsockname(fd)
 	if err != nil {
 		return nil, os.NewSyscallError("getsockname", err)
 	}
@@ -100,13 +345,62 @@ func listenAddr(addr net.Addr) (net.Listener, error) {
 
 	l, err := net.FileListener(f)
 	if err != nil {
+		os.Remove(unlink)
 		return nil, err
 	}
-	return makeListener(l, name), nil
+	l = makeListener(l, name)
+	if u, ok := l.(*UnixListener); ok {
+		u.path, u.unlink = unlink, true
+	}
+	return l, nil
+}
+
+// removeStaleUnixSocket removes the unix socket file at addr if no process is
+// accepting connections on it. The function returns true if the address may
+// now be available for binding.
+//
+// The state of the socket is probed by connecting to it: only ECONNREFUSED,
+// which indicates that nothing is listening, is interpreted as a stale socket.
+// When a server is listening, it observes a connection which is closed
+// immediately without sending data. The check is inherently racy: a server
+// which has bound the path but not yet called listen also refuses
+// connections, and its socket file is removed. Applications must not enable
+// RemoveStaleUnixSocket when multiple processes may compete for the path.
+func removeStaleUnixSocket(addr wasisyscall.Sockaddr) bool {
+	a, ok := addr.(*wasisyscall.SockaddrUnix)
+	if !ok {
+		return false
+	}
+	fd, err := wasisyscall.Socket(AF_UNIX, SOCK_STREAM, 0)
+	if err != nil {
+		return false
+	}
+	err = wasisyscall.Connect(fd, &wasisyscall.SockaddrUnix{Name: a.Name})
+	syscall.Close(fd)
+
+	switch err {
+	case syscall.ECONNREFUSED:
+		return os.Remove(a.Name) == nil
+	case syscall.ENOENT:
+		// The file was removed concurrently.
+		return true
+	default:
+		// The socket is alive, or we could not determine its state; either way
+		// we must not remove it.
+		return false
+	}
 }
 
 func listenPacketAddr(addr net.Addr) (net.PacketConn, error) {
-	fd, err := socket(family(addr), SOCK_DGRAM, 0)
+	bindAddr, err := socketAddress(addr)
+	if err != nil {
+		return nil, os.NewSyscallError("bind", err)
+	}
+	return listenPacketSockaddr(bindAddr)
+}
+
+func listenPacketSockaddr(bindAddr wasisyscall.Sockaddr) (net.PacketConn, error) {
+	fd, err := wasisyscall.Socket(sockaddrFamily(bindAddr), SOCK_DGRAM, 0)
 	if err != nil {
 		return nil, os.NewSyscallError("socket", err)
 	}
@@ -123,22 +417,22 @@ func listenPacketAddr(addr net.Addr) (net.PacketConn, error) {
 		return nil, err
 	}
 
-	bindAddr, err := socketAddress(addr)
-	if err != nil {
-		return nil, os.NewSyscallError("bind", err)
-	}
-	if err := bind(fd, bindAddr); err != nil {
+	if err := wasisyscall.Bind(fd, bindAddr); err != nil {
 		return nil, os.NewSyscallError("bind", err)
 	}
 
-	name, err := getsockname(fd)
+	name, err := wasisyscall.Getsockname(fd)
 	if err != nil {
 		return nil, os.NewSyscallError("getsockname", err)
 	}
 
 	f := os.NewFile(uintptr(fd), "")
 	fd = -1 // now the *os.File owns the file descriptor
-	return makePacketConn(f, name, nil), nil
+	c := makePacketConn(f, name, nil)
+	if _, unix := name.(*wasisyscall.SockaddrUnix); unix {
+		return newUnixPacketConn(c), nil
+	}
+	return &UDPConn{c}, nil
 }
 
 type listener struct{ net.Listener }
@@ -151,19 +445,97 @@ func (l *listener) Accept() (net.Conn, error) {
 	return makeConn(c)
 }
 
-type unixListener struct {
-	listener
-	addr net.UnixAddr
+// multiListener is a net.Listener accepting connections from multiple sockets,
+// which is used when the IPv6 sockets of the host are not dual-stack.
+type multiListener struct {
+	listeners []net.Listener
+	conns     chan acceptResult
+	done      chan struct{}
+	once      sync.Once
+}
+
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+func newMultiListener(listeners []net.Listener) *multiListener {
+	l := &multiListener{
+		listeners: listeners,
+		conns:     make(chan acceptResult),
+		done:      make(chan struct{}),
+	}
+	for _, lstn := range listeners {
+		go l.serve(lstn)
+	}
+	return l
+}
+
+func (l *multiListener) serve(lstn net.Listener) {
+	for {
+		c, err := lstn.Accept()
+		select {
+		case l.conns <- acceptResult{c, err}:
+			if err != nil && !isTemporary(err) {
+				// The error is reported once, then both sockets are closed
+				// so the listener does not silently stop accepting
+				// connections for one of the address families.
+				l.Close()
+				return
+			}
+		case <-l.done:
+			if c != nil {
+				c.Close()
+			}
+			return
+		}
+	}
+}
+
+// isTemporary returns true if err is a temporary accept error, such as the
+// process running out of file descriptors, after which the listener can keep
+// accepting connections.
+func isTemporary(err error) bool {
+	var e interface{ Temporary() bool }
+	return errors.As(err, &e) && e.Temporary()
+}
+
+func (l *multiListener) Accept() (net.Conn, error) {
+	select {
+	case r := <-l.conns:
+		return r.conn, r.err
+	case <-l.done:
+		return nil, &net.OpError{
+			Op:   "accept",
+			Net:  l.Addr().Network(),
+			Addr: l.Addr(),
+			Err:  net.ErrClosed,
+		}
+	}
+}
+
+func (l *multiListener) Close() (err error) {
+	l.once.Do(func() {
+		close(l.done)
+		for _, lstn := range l.listeners {
+			if closeErr := lstn.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+	})
+	return err
 }
 
-func (l *unixListener) Addr() net.Addr {
-	return &l.addr
+// Addr returns the address of the first listener, which is the IPv6 wildcard
+// address when listening on both IPv4 and IPv6.
+func (l *multiListener) Addr() net.Addr {
+	return l.listeners[0].Addr()
 }
 
-func makeListener(l net.Listener, addr sockaddr) net.Listener {
+func makeListener(l net.Listener, addr wasisyscall.Sockaddr) net.Listener {
 	switch addr.(type) {
-	case *sockaddrUnix:
-		l = &unixListener{listener: listener{l}}
+	case *wasisyscall.SockaddrUnix:
+		l = &UnixListener{listener: listener{l}}
 	default:
 		l = &listener{l}
 	}
@@ -171,9 +543,19 @@ func makeListener(l net.Listener, addr sockaddr) net.Listener {
 	return l
 }
 
-func makePacketConn(f *os.File, laddr, raddr sockaddr) *packetConn {
+// socketFile is the subset of the *os.File methods used by packetConn, which
+// are also implemented by the connections returned by net.FileConn.
+type socketFile interface {
+	io.WriteCloser
+	syscall.Conn
+	SetDeadline(time.Time) error
+	SetReadDeadline(time.Time) error
+	SetWriteDeadline(time.Time) error
+}
+
+func makePacketConn(f socketFile, laddr, raddr wasisyscall.Sockaddr) *packetConn {
 	conn := &packetConn{file: f}
-	if _, unix := laddr.(*sockaddrUnix); unix {
+	if _, unix := laddr.(*wasisyscall.SockaddrUnix); unix {
 		conn.laddr = new(net.UnixAddr)
 		conn.raddr = new(net.UnixAddr)
 	} else {
@@ -186,20 +568,98 @@ func makePacketConn(f *os.File, laddr, raddr sockaddr) *packetConn {
 	return conn
 }
 
+// packetOp holds the state of a read or write operation on a packetConn.
+//
+// The values are pooled, and the functions passed to the syscall.RawConn are
+// bound once when the values are created, so that reading or writing datagrams
+// does not allocate memory.
+type packetOp struct {
+	buf    []byte
+	addr   wasisyscall.RawSockaddrAny
+	port   int
+	oflags int
+	n      int
+	err    error
+	recv   func(fd uintptr) bool
+	send   func(fd uintptr) bool
+}
+
+var packetOps = sync.Pool{
+	New: func() any {
+		op := new(packetOp)
+		op.recv = op.recvfrom
+		op.send = op.sendto
+		return op
+	},
+}
+
+func getPacketOp(b []byte) *packetOp {
+	op := packetOps.Get().(*packetOp)
+	op.buf = b
+	return op
+}
+
+func putPacketOp(op *packetOp) {
+	op.buf = nil
+	op.addr = wasisyscall.RawSockaddrAny{}
+	op.port, op.oflags, op.n, op.err = 0, 0, 0, nil
+	packetOps.Put(op)
+}
+
+func (op *packetOp) recvfrom(fd uintptr) bool {
+	op.n, op.port, op.oflags, op.err = wasisyscall.RecvfromAny(int(fd), op.buf, 0, &op.addr)
+	return op.err != syscall.EAGAIN
+}
+
+func (op *packetOp) sendto(fd uintptr) bool {
+	op.n, op.err = wasisyscall.SendtoAny(int(fd), op.buf, 0, &op.addr, op.port)
+	return op.err != syscall.EAGAIN
+}
+
+func (op *packetOp) addrPort() netip.AddrPort {
+	var addr netip.Addr
+	switch op.addr.Family {
+	case AF_INET:
+		addr = netip.AddrFrom4(([4]byte)(op.addr.Addr[:4]))
+	case AF_INET6:
+		addr = netip.AddrFrom16(([16]byte)(op.addr.Addr[:16]))
+	}
+	return netip.AddrPortFrom(addr, uint16(op.port))
+}
+
+func (op *packetOp) setAddrPort(addrPort netip.AddrPort) {
+	addr := addrPort.Addr()
+	if addr.Is4() {
+		op.addr.Family = AF_INET
+		ipv4 := addr.As4()
+		copy(op.addr.Addr[:], ipv4[:])
+	} else {
+		op.addr.Family = AF_INET6
+		ipv6 := addr.As16()
+		copy(op.addr.Addr[:], ipv6[:])
+	}
+	op.port = int(addrPort.Port())
+}
+
 type packetConn struct {
-	file  *os.File
-	laddr net.Addr
-	raddr net.Addr
-	conn  syscall.RawConn
+	file   socketFile
+	laddr  net.Addr
+	raddr  net.Addr
+	conn   syscall.RawConn
+	unlink string
 }
 
 func (c *packetConn) Close() error {
-	return c.file.Close()
+	err := c.file.Close()
+	if c.unlink != "" && err == nil {
+		os.Remove(c.unlink)
+	}
+	return err
 }
 
 func (c *packetConn) CloseRead() (err error) {
 	rawConnErr := c.conn.Control(func(fd uintptr) {
-		err = shutdown(int(fd), 1)
+		err = wasisyscall.Shutdown(int(fd), 1)
 	})
 	if rawConnErr != nil {
 		err = rawConnErr
@@ -209,7 +669,7 @@ func (c *packetConn) CloseRead() (err error) {
 
 func (c *packetConn) CloseWrite() (err error) {
 	rawConnErr := c.conn.Control(func(fd uintptr) {
-		err = shutdown(int(fd), 2)
+		err = wasisyscall.Shutdown(int(fd), 2)
 	})
 	if rawConnErr != nil {
 		err = rawConnErr
@@ -233,29 +693,15 @@ func (c *packetConn) ReadFrom(b []byte) (n int, addr net.Addr, err error) {
 }
 
 func (c *packetConn) ReadMsgUnix(b, oob []byte) (n, oobn, flags int, addr *net.UnixAddr, err error) {
-	rawConnErr := c.conn.Read(func(fd uintptr) (done bool) {
-		var raw rawSockaddrAny
-		var oflags int32
-		n, raw, _, oflags, err = recvfrom(int(fd), [][]byte{b}, 0)
-		if err == syscall.EAGAIN {
-			return false
+	op := getPacketOp(b)
+	defer putPacketOp(op)
+	if err = c.recv(op); err == nil {
+		addr = &net.UnixAddr{
+			Net:  "unixgram",
+			Name: string(op.addr.Addr[:strlen(op.addr.Addr[:])]),
 		}
-		if err == syscall.EINVAL {
-			// This error occurs when the socket is shutdown asynchronusly
-			// by a call to CloseRead.
-			n, err = 0, io.EOF
-		} else {
-			addr = &net.UnixAddr{
-				Net:  "unixgram",
-				Name: string(raw.addr[:strlen(raw.addr[:])]),
-			}
-		}
-		flags = int(oflags)
-		return true
-	})
-	if rawConnErr != nil {
-		err = rawConnErr
 	}
+	n, flags = op.n, op.oflags
 	if n == 0 && err == nil {
 		err = io.EOF
 	}
@@ -268,40 +714,38 @@ func (c *packetConn) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UD
 }
 
 func (c *packetConn) ReadMsgUDPAddrPort(b, oob []byte) (n, oobn, flags int, addrPort netip.AddrPort, err error) {
-	rawConnErr := c.conn.Read(func(fd uintptr) (done bool) {
-		var raw rawSockaddrAny
-		var port int32
-		var oflags int32
-		n, raw, port, oflags, err = recvfrom(int(fd), [][]byte{b}, 0)
-		if err == syscall.EAGAIN {
-			return false
-		}
-		if err == syscall.EINVAL {
-			// This error occurs when the socket is shutdown asynchronusly
-			// by a call to CloseRead.
-			n, err = 0, io.EOF
-			return true
-		}
-		var addr netip.Addr
-		switch raw.family {
-		case AF_INET:
-			addr = netip.AddrFrom4(([4]byte)(raw.addr[:4]))
-		case AF_INET6:
-			addr = netip.AddrFrom16(([16]byte)(raw.addr[:16]))
-		}
-		addrPort = netip.AddrPortFrom(addr, uint16(port))
-		flags = int(oflags)
-		return true
-	})
-	if rawConnErr != nil {
-		err = rawConnErr
+	op := getPacketOp(b)
+	defer putPacketOp(op)
+	if err = c.recv(op); err == nil {
+		addrPort = op.addrPort()
 	}
+	n, flags = op.n, op.oflags
 	if n == 0 && err == nil {
 		err = io.EOF
 	}
 	return
 }
 
+func (c *packetConn) recv(op *packetOp) error {
+	if err := c.conn.Read(op.recv); err != nil {
+		return err
+	}
+	if op.err == syscall.EINVAL {
+		// This error occurs when the socket is shutdown asynchronusly by a
+		// call to CloseRead.
+		op.n = 0
+		return io.EOF
+	}
+	return op.err
+}
+
+func (c *packetConn) send(op *packetOp) error {
+	if err := c.conn.Write(op.send); err != nil {
+		return err
+	}
+	return op.err
+}
+
 func (c *packetConn) Write(b []byte) (int, error) {
 	return c.file.Write(b)
 }
@@ -329,16 +773,12 @@ func (c *packetConn) WriteTo(b []byte, addr net.Addr) (int, error) {
 }
 
 func (c *packetConn) WriteMsgUnix(b, oob []byte, addr *net.UnixAddr) (n, oobn int, err error) {
-	rawConnErr := c.conn.Write(func(fd uintptr) (done bool) {
-		raw := rawSockaddrAny{family: AF_UNIX}
-		copy(raw.addr[:], addr.Name)
-		n, err = sendto(int(fd), [][]byte{b}, raw, 0, 0)
-		return err != syscall.EAGAIN
-	})
-	if rawConnErr != nil {
-		err = rawConnErr
-	}
-	return
+	op := getPacketOp(b)
+	defer putPacketOp(op)
+	op.addr.Family = AF_UNIX
+	copy(op.addr.Addr[:], addr.Name)
+	err = c.send(op)
+	return op.n, 0, err
 }
 
 func (c *packetConn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
@@ -346,26 +786,11 @@ func (c *packetConn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int,
 }
 
 func (c *packetConn) WriteMsgUDPAddrPort(b, oob []byte, addrPort netip.AddrPort) (n, oobn int, err error) {
-	rawConnErr := c.conn.Write(func(fd uintptr) (done bool) {
-		var raw rawSockaddrAny
-		addr := addrPort.Addr()
-		port := addrPort.Port()
-		if addr.Is4() {
-			raw.family = AF_INET
-			ipv4 := addr.As4()
-			copy(raw.addr[:], ipv4[:])
-		} else {
-			raw.family = AF_INET6
-			ipv6 := addr.As16()
-			copy(raw.addr[:], ipv6[:])
-		}
-		n, err = sendto(int(fd), [][]byte{b}, raw, int32(port), 0)
-		return err != syscall.EAGAIN
-	})
-	if rawConnErr != nil {
-		err = rawConnErr
-	}
-	return
+	op := getPacketOp(b)
+	defer putPacketOp(op)
+	op.setAddrPort(addrPort)
+	err = c.send(op)
+	return op.n, 0, err
 }
 
 func (c *packetConn) LocalAddr() net.Addr {
@@ -376,6 +801,49 @@ func (c *packetConn) RemoteAddr() net.Addr {
 	return c.raddr
 }
 
+// LocalAddrPort returns the local address as a netip.AddrPort, or the zero
+// value if the connection is not an IP socket.
+func (c *packetConn) LocalAddrPort() netip.AddrPort {
+	if a, ok := c.laddr.(*net.UDPAddr); ok {
+		return a.AddrPort()
+	}
+	return netip.AddrPort{}
+}
+
+// RemoteAddrPort returns the remote address as a netip.AddrPort, or the zero
+// value if the connection is not an IP socket or is not connected.
+func (c *packetConn) RemoteAddrPort() netip.AddrPort {
+	if a, ok := c.raddr.(*net.UDPAddr); ok && a.IP != nil {
+		return a.AddrPort()
+	}
+	return netip.AddrPort{}
+}
+
+func (c *packetConn) SetReadBuffer(bytes int) error {
+	return c.setsockopt(SO_RCVBUF, bytes)
+}
+
+func (c *packetConn) SetWriteBuffer(bytes int) error {
+	return c.setsockopt(SO_SNDBUF, bytes)
+}
+
+func (c *packetConn) setsockopt(opt, value int) (err error) {
+	rawConnErr := c.conn.Control(func(fd uintptr) {
+		err = wasisyscall.SetsockoptInt(int(fd), SOL_SOCKET, opt, value)
+	})
+	if rawConnErr != nil {
+		err = rawConnErr
+	}
+	if err != nil {
+		err = os.NewSyscallError("setsockopt", err)
+	}
+	return
+}
+
+func (c *packetConn) SyscallConn() (syscall.RawConn, error) {
+	return c.conn, nil
+}
+
 func (c *packetConn) SetDeadline(t time.Time) error {
 	return c.file.SetDeadline(t)
 }
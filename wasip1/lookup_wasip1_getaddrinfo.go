@@ -7,18 +7,20 @@ import (
 	"net"
 	"os"
 	"strconv"
+
+	wasisyscall "github.com/stealthrocket/net/wasip1/syscall"
 )
 
 func lookupAddr(ctx context.Context, op, network, address string) ([]net.Addr, error) {
-	var hints addrInfo
+	var hints wasisyscall.Addrinfo
 
 	switch network {
 	case "tcp", "tcp4", "tcp6":
-		hints.socketType = SOCK_STREAM
-		hints.protocol = IPPROTO_TCP
+		hints.SocketType = SOCK_STREAM
+		hints.Protocol = IPPROTO_TCP
 	case "udp", "udp4", "udp6":
-		hints.socketType = SOCK_DGRAM
-		hints.protocol = IPPROTO_UDP
+		hints.SocketType = SOCK_DGRAM
+		hints.Protocol = IPPROTO_UDP
 	case "unix", "unixgram":
 		return []net.Addr{&net.UnixAddr{Name: address, Net: network}}, nil
 	default:
@@ -27,11 +29,11 @@ func lookupAddr(ctx context.Context, op, network, address string) ([]net.Addr, e
 
 	switch network {
 	case "tcp", "udp":
-		hints.family = AF_UNSPEC
+		hints.Family = AF_UNSPEC
 	case "tcp4", "udp4":
-		hints.family = AF_INET
+		hints.Family = AF_INET
 	case "tcp6", "udp6":
-		hints.family = AF_INET6
+		hints.Family = AF_INET6
 	}
 
 	hostname, service, err := net.SplitHostPort(address)
@@ -39,17 +41,17 @@ func lookupAddr(ctx context.Context, op, network, address string) ([]net.Addr, e
 		return nil, err
 	}
 	if ip := net.ParseIP(hostname); ip != nil {
-		hints.flags |= AI_NUMERICHOST
+		hints.Flags |= AI_NUMERICHOST
 	}
 	if _, err = strconv.Atoi(service); err == nil {
-		hints.flags |= AI_NUMERICSERV
+		hints.Flags |= AI_NUMERICSERV
 	}
 	if op == "listen" && hostname == "" {
-		hints.flags |= AI_PASSIVE
+		hints.Flags |= AI_PASSIVE
 	}
 
-	results := make([]addrInfo, 8)
-	n, err := getaddrinfo(hostname, service, &hints, results)
+	results := make([]wasisyscall.Addrinfo, 8)
+	n, err := wasisyscall.Getaddrinfo(hostname, service, &hints, results)
 	if err != nil {
 		addr := &netAddr{network, address}
 		return nil, newOpError(op, addr, os.NewSyscallError("getaddrinfo", err))
@@ -59,13 +61,13 @@ func lookupAddr(ctx context.Context, op, network, address string) ([]net.Addr, e
 	for _, r := range results[:n] {
 		var ip net.IP
 		var port int
-		switch a := r.address.(type) {
-		case *sockaddrInet4:
-			ip = a.addr[:]
-			port = int(a.port)
-		case *sockaddrInet6:
-			ip = a.addr[:]
-			port = int(a.port)
+		switch a := r.Addr.(type) {
+		case *wasisyscall.SockaddrInet4:
+			ip = a.Addr[:]
+			port = a.Port
+		case *wasisyscall.SockaddrInet6:
+			ip = a.Addr[:]
+			port = a.Port
 		}
 		switch network {
 		case "tcp", "tcp4", "tcp6":
@@ -6,8 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/netip"
 	"os"
 	"syscall"
+
+	wasisyscall "github.com/stealthrocket/net/wasip1/syscall"
 )
 
 func newOpError(op string, addr net.Addr, err error) error {
@@ -19,29 +22,32 @@ func newOpError(op string, addr net.Addr, err error) error {
 	}
 }
 
+// unknownNetworkError returns the error reported by the typed dial and listen
+// functions when the network is invalid. The address is nil when the function
+// was called with a nil address.
+func unknownNetworkError(op, network string, addr net.Addr) error {
+	return &net.OpError{
+		Op:   op,
+		Net:  network,
+		Addr: addr,
+		Err:  net.UnknownNetworkError(network),
+	}
+}
+
 type netAddr struct{ network, address string }
 
 func (na *netAddr) Network() string { return na.address }
 func (na *netAddr) String() string  { return na.address }
 
-func family(addr net.Addr) int {
-	var ip net.IP
-	switch a := addr.(type) {
-	case *net.IPAddr:
-		ip = a.IP
-	case *net.TCPAddr:
-		ip = a.IP
-	case *net.UDPAddr:
-		ip = a.IP
-	case *net.UnixAddr:
+func sockaddrFamily(addr wasisyscall.Sockaddr) int {
+	switch addr.(type) {
+	case *wasisyscall.SockaddrInet6:
+		return AF_INET6
+	case *wasisyscall.SockaddrUnix:
 		return AF_UNIX
-	}
-	if ip.To4() != nil {
+	default:
 		return AF_INET
-	} else if len(ip) == net.IPv6len {
-		return AF_INET6
 	}
-	return AF_INET
 }
 
 func socketType(addr net.Addr) (int, error) {
@@ -55,7 +61,7 @@ func socketType(addr net.Addr) (int, error) {
 	}
 }
 
-func socketAddress(addr net.Addr) (sockaddr, error) {
+func socketAddress(addr net.Addr) (wasisyscall.Sockaddr, error) {
 	var ip net.IP
 	var port int
 	switch a := addr.(type) {
@@ -66,12 +72,13 @@ func socketAddress(addr net.Addr) (sockaddr, error) {
 	case *net.UDPAddr:
 		ip, port = a.IP, a.Port
 	case *net.UnixAddr:
-		return &sockaddrUnix{name: a.Name}, nil
+		return &wasisyscall.SockaddrUnix{Name: a.Name}, nil
 	}
 	if ipv4 := ip.To4(); ipv4 != nil {
-		return &sockaddrInet4{addr: ([4]byte)(ipv4), port: uint32(port)}, nil
+		return &wasisyscall.SockaddrInet4{Addr: ([4]byte)(ipv4), Port: port}, nil
 	} else if len(ip) == net.IPv6len {
-		return &sockaddrInet6{addr: ([16]byte)(ip), port: uint32(port)}, nil
+		// The zone is dropped, see the package documentation.
+		return &wasisyscall.SockaddrInet6{Addr: ([16]byte)(ip), Port: port}, nil
 	} else {
 		return nil, &net.AddrError{
 			Err:  "unsupported address type",
@@ -80,6 +87,30 @@ func socketAddress(addr net.Addr) (sockaddr, error) {
 	}
 }
 
+// addrPortSockaddr converts addr to a socket address, taking the network into
+// account to validate the address family.
+func addrPortSockaddr(network string, addr netip.AddrPort) (wasisyscall.Sockaddr, error) {
+	ip := addr.Addr()
+	switch network {
+	case "tcp4", "udp4":
+		if !ip.Unmap().Is4() {
+			return nil, &net.AddrError{Err: "non-IPv4 address", Addr: addr.String()}
+		}
+	case "tcp6", "udp6":
+		if !ip.Is6() || ip.Is4In6() {
+			return nil, &net.AddrError{Err: "non-IPv6 address", Addr: addr.String()}
+		}
+	}
+	switch {
+	case ip.Is4() || ip.Is4In6():
+		return &wasisyscall.SockaddrInet4{Addr: ip.Unmap().As4(), Port: int(addr.Port())}, nil
+	case ip.Is6():
+		return &wasisyscall.SockaddrInet6{Addr: ip.As16(), Port: int(addr.Port())}, nil
+	default:
+		return nil, &net.AddrError{Err: "invalid address", Addr: addr.String()}
+	}
+}
+
 // In Go 1.21, the net package cannot initialize the local and remote addresses
 // of network connections. For this reason, we use this function to retreive the
 // addresses and return a wrapped net.Conn with LocalAddr/RemoteAddr implemented.
@@ -94,21 +125,21 @@ func makeConn(c net.Conn) (net.Conn, error) {
 		return nil, fmt.Errorf("syscall.Conn.SyscallConn: %w", err)
 	}
 	rawConnErr := rawConn.Control(func(fd uintptr) {
-		var addr sockaddr
-		var peer sockaddr
+		var addr wasisyscall.Sockaddr
+		var peer wasisyscall.Sockaddr
 
-		if addr, err = getsockname(int(fd)); err != nil {
+		if addr, err = wasisyscall.Getsockname(int(fd)); err != nil {
 			err = os.NewSyscallError("getsockname", err)
 			return
 		}
 
-		if peer, err = getpeername(int(fd)); err != nil {
+		if peer, err = wasisyscall.Getpeername(int(fd)); err != nil {
 			err = os.NewSyscallError("getpeername", err)
 			return
 		}
 
-		if _, unix := addr.(*sockaddrUnix); unix {
-			c = &unixConn{Conn: c}
+		if _, unix := addr.(*wasisyscall.SockaddrUnix); unix {
+			c = &UnixConn{conn: c}
 		}
 
 		setNetAddr(SOCK_STREAM, c.LocalAddr(), addr)
@@ -124,7 +155,7 @@ func makeConn(c net.Conn) (net.Conn, error) {
 	return c, nil
 }
 
-func setNetAddr(sotype int, dst net.Addr, src sockaddr) {
+func setNetAddr(sotype int, dst net.Addr, src wasisyscall.Sockaddr) {
 	switch a := dst.(type) {
 	case *net.IPAddr:
 		a.IP, _ = sockaddrIPAndPort(src)
@@ -143,21 +174,28 @@ func setNetAddr(sotype int, dst net.Addr, src sockaddr) {
 	}
 }
 
-func sockaddrName(addr sockaddr) string {
+func sockaddrName(addr wasisyscall.Sockaddr) string {
 	switch a := addr.(type) {
-	case *sockaddrUnix:
-		return a.name
+	case *wasisyscall.SockaddrUnix:
+		return a.Name
 	default:
 		return ""
 	}
 }
 
-func sockaddrIPAndPort(addr sockaddr) (net.IP, int) {
+func strlen(b []byte) (n int) {
+	for n < len(b) && b[n] != 0 {
+		n++
+	}
+	return n
+}
+
+func sockaddrIPAndPort(addr wasisyscall.Sockaddr) (net.IP, int) {
 	switch a := addr.(type) {
-	case *sockaddrInet4:
-		return net.IP(a.addr[:]), int(a.port)
-	case *sockaddrInet6:
-		return net.IP(a.addr[:]), int(a.port)
+	case *wasisyscall.SockaddrInet4:
+		return net.IP(a.Addr[:]), a.Port
+	case *wasisyscall.SockaddrInet6:
+		return net.IP(a.Addr[:]), a.Port
 	default:
 		return nil, 0
 	}
@@ -171,7 +209,7 @@ func setNonBlock(fd int) error {
 }
 
 func setReuseAddress(fd int) error {
-	if err := setsockopt(fd, SOL_SOCKET, SO_REUSEADDR, 1); err != nil {
+	if err := wasisyscall.SetsockoptInt(fd, SOL_SOCKET, SO_REUSEADDR, 1); err != nil {
 		// The runtime may not support the option; if that's the case and the
 		// address is already in use, binding the socket will fail and we will
 		// report the error then.
@@ -3,10 +3,19 @@
 package wasip1_test
 
 import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
 	"net"
+	"net/netip"
+	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stealthrocket/net/wasip1"
 	"golang.org/x/net/nettest"
@@ -137,23 +146,18 @@ func TestPacketConn(t *testing.T) {
 			rb2 := make([]byte, 128)
 			wb := []byte("PACKETCONN TEST")
 
-			// On unix the domain, the local address of connected sockets is
-			// empty which makes it impossible for the listening connection
-			// to send it a packet.
-			if network != "unixgram" {
-				if n, err := c1.WriteTo(wb, c2.LocalAddr()); err != nil {
-					t.Fatal(err)
-				} else if n != len(wb) {
-					t.Fatalf("write with wrong number of bytes: want=%d got=%d", len(wb), n)
-				}
+			if n, err := c1.WriteTo(wb, c2.LocalAddr()); err != nil {
+				t.Fatal(err)
+			} else if n != len(wb) {
+				t.Fatalf("write with wrong number of bytes: want=%d got=%d", len(wb), n)
+			}
 
-				if n, addr, err := c2.ReadFrom(rb2); err != nil {
-					t.Fatal(err)
-				} else if n != len(wb) {
-					t.Fatalf("read with wrong number of bytes: want=%d got=%d", len(wb), n)
-				} else if !reflect.DeepEqual(addr, c1.LocalAddr()) {
-					t.Fatalf("read from wrong address: want=%s got=%s", c1.LocalAddr(), addr)
-				}
+			if n, addr, err := c2.ReadFrom(rb2); err != nil {
+				t.Fatal(err)
+			} else if n != len(wb) {
+				t.Fatalf("read with wrong number of bytes: want=%d got=%d", len(wb), n)
+			} else if !reflect.DeepEqual(addr, c1.LocalAddr()) {
+				t.Fatalf("read from wrong address: want=%s got=%s", c1.LocalAddr(), addr)
 			}
 
 			if n, err := c.Write(wb); err != nil {
@@ -173,3 +177,745 @@ func TestPacketConn(t *testing.T) {
 		})
 	}
 }
+
+func TestUnixListener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wasip1.sock")
+
+	l, err := wasip1.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*wasip1.UnixListener).SetUnlinkOnClose(false)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("socket file was removed: %v", err)
+	}
+
+	if _, err := wasip1.Listen("unix", path); !errors.Is(err, syscall.EADDRINUSE) {
+		t.Fatalf("listening on a stale socket returned the wrong error: want=%v got=%v", syscall.EADDRINUSE, err)
+	}
+
+	lc := &wasip1.ListenConfig{RemoveStaleUnixSocket: true}
+	l, err = lc.Listen(context.Background(), "unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lc.Listen(context.Background(), "unix", path); !errors.Is(err, syscall.EADDRINUSE) {
+		t.Fatalf("listening on a live socket returned the wrong error: want=%v got=%v", syscall.EADDRINUSE, err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("socket file was not removed: %v", err)
+	}
+}
+
+func TestUnixConn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wasip1.sock")
+
+	l, err := wasip1.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	c1, err := wasip1.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+
+	c2, err := l.(*wasip1.UnixListener).AcceptUnix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+
+	if _, err := c1.(*wasip1.UnixConn).WriteToUnix([]byte("hello"), &net.UnixAddr{Name: path, Net: "unix"}); !errors.Is(err, net.ErrWriteToConnected) {
+		t.Fatalf("writing to a connected socket returned the wrong error: want=%v got=%v", net.ErrWriteToConnected, err)
+	}
+	if _, _, err := c1.(*wasip1.UnixConn).WriteMsgUnix([]byte("hello"), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	b := make([]byte, 32)
+	n, _, err := c2.ReadFromUnix(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "hello" {
+		t.Fatalf("wrong message received: %q", b[:n])
+	}
+	if addr := c2.LocalAddr().String(); addr != path {
+		t.Fatalf("wrong local address: want=%s got=%s", path, addr)
+	}
+}
+
+func TestListenDualStack(t *testing.T) {
+	l, err := wasip1.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	port := l.Addr().(*net.TCPAddr).Port
+
+	for _, host := range []string{"127.0.0.1", "::1"} {
+		c, err := wasip1.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		a, err := l.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.Close()
+	}
+}
+
+func TestListenPacketDualStack(t *testing.T) {
+	p, err := wasip1.ListenPacket("udp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	laddr := p.LocalAddr().(*net.UDPAddr)
+	hosts := []string{"127.0.0.1", "::1"}
+	if laddr.IP.To4() != nil {
+		// The host does not support IPv6.
+		hosts = hosts[:1]
+	}
+
+	for _, host := range hosts {
+		c, err := wasip1.Dial("udp", net.JoinHostPort(host, strconv.Itoa(laddr.Port)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		if _, err := c.Write([]byte(host)); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 32)
+		n, addr, err := p.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) && host == "127.0.0.1" {
+				t.Skip("IPv6 sockets of the host are not dual-stack")
+			}
+			t.Fatal(err)
+		}
+		if string(buf[:n]) != host {
+			t.Fatalf("wrong datagram received from %s: %q", addr, buf[:n])
+		}
+		if addr.(*net.UDPAddr).Port != c.LocalAddr().(*net.UDPAddr).Port {
+			t.Fatalf("wrong peer address: want=%s got=%s", c.LocalAddr(), addr)
+		}
+	}
+}
+
+func TestAddrPort(t *testing.T) {
+	loopback := netip.AddrPortFrom(netip.MustParseAddr("127.0.0.1"), 0)
+
+	l, err := wasip1.ListenAddrPort("tcp", loopback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	c, err := wasip1.DialAddrPort(context.Background(), "tcp", l.Addr().(*net.TCPAddr).AddrPort())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	if _, err := wasip1.DialAddrPort(context.Background(), "tcp6", loopback); err == nil {
+		t.Fatal("dialing an IPv4 address on tcp6 must fail")
+	}
+
+	p, err := wasip1.ListenPacketAddrPort("udp", loopback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	type addrPortConn interface {
+		LocalAddrPort() netip.AddrPort
+		RemoteAddrPort() netip.AddrPort
+	}
+	laddr := p.(addrPortConn).LocalAddrPort()
+	if laddr.Addr() != loopback.Addr() || laddr.Port() == 0 {
+		t.Fatalf("wrong local address: %s", laddr)
+	}
+
+	u, err := wasip1.DialAddrPort(context.Background(), "udp", laddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer u.Close()
+
+	if raddr := u.(addrPortConn).RemoteAddrPort(); raddr != laddr {
+		t.Fatalf("wrong remote address: want=%s got=%s", laddr, raddr)
+	}
+}
+
+func TestTypedConns(t *testing.T) {
+	t.Run("tcp", func(t *testing.T) {
+		l, err := wasip1.ListenTCP("tcp4", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+
+		c1, err := wasip1.DialTCP("tcp", nil, l.Addr().(*net.TCPAddr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c1.Close()
+
+		c2, err := l.AcceptTCP()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c2.Close()
+
+		if c1.LocalAddrPort() != c2.RemoteAddrPort() {
+			t.Fatalf("address mismatch: %s != %s", c1.LocalAddrPort(), c2.RemoteAddrPort())
+		}
+		if err := c1.SetNoDelay(true); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("udp", func(t *testing.T) {
+		c1, err := wasip1.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c1.Close()
+
+		c2, err := wasip1.DialUDP("udp", nil, c1.LocalAddr().(*net.UDPAddr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c2.Close()
+
+		if _, err := c2.Write([]byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+		b := make([]byte, 32)
+		n, addr, err := c1.ReadFromUDPAddrPort(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b[:n]) != "hello" {
+			t.Fatalf("wrong datagram received: %q", b[:n])
+		}
+		if addr != c2.LocalAddrPort() {
+			t.Fatalf("wrong address: want=%s got=%s", c2.LocalAddrPort(), addr)
+		}
+	})
+
+	t.Run("unix", func(t *testing.T) {
+		addr := &net.UnixAddr{Name: filepath.Join(t.TempDir(), "wasip1.sock"), Net: "unix"}
+
+		l, err := wasip1.ListenUnix("unix", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+
+		c1, err := wasip1.DialUnix("unix", nil, addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c1.Close()
+
+		c2, err := l.AcceptUnix()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c2.Close()
+	})
+}
+
+func TestTypedConnsUnknownNetwork(t *testing.T) {
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"DialTCP", func() error { _, err := wasip1.DialTCP("udp", nil, nil); return err }},
+		{"ListenTCP", func() error { _, err := wasip1.ListenTCP("udp", nil); return err }},
+		{"DialUDP", func() error { _, err := wasip1.DialUDP("tcp", nil, nil); return err }},
+		{"ListenUDP", func() error { _, err := wasip1.ListenUDP("tcp", nil); return err }},
+		{"DialUnix", func() error { _, err := wasip1.DialUnix("udp", nil, nil); return err }},
+		{"ListenUnix", func() error { _, err := wasip1.ListenUnix("tcp", nil); return err }},
+		{"ListenUnixgram", func() error { _, err := wasip1.ListenUnixgram("unix", nil); return err }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.call()
+			var opErr *net.OpError
+			if !errors.As(err, &opErr) {
+				t.Fatalf("expected *net.OpError, got %v", err)
+			}
+			var unknown net.UnknownNetworkError
+			if !errors.As(err, &unknown) {
+				t.Fatalf("expected net.UnknownNetworkError, got %v", err)
+			}
+			if opErr.Net != string(unknown) {
+				t.Fatalf("wrong network: want=%q got=%q", unknown, opErr.Net)
+			}
+		})
+	}
+}
+
+func TestDialCancel(t *testing.T) {
+	// The address is not routable, so the connection remains in progress until
+	// the context is canceled.
+	const address = "10.255.255.1:80"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	c, err := wasip1.DialContext(ctx, "tcp", address)
+	if err == nil {
+		c.Close()
+		t.Skip("connected to non-routable address")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Skipf("network does not blackhole non-routable addresses: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("dial took too long to be interrupted: %s", elapsed)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	if c, err := wasip1.DialContext(ctx, "tcp", address); err == nil {
+		c.Close()
+		t.Fatal("connected to non-routable address")
+	} else if !errors.Is(err, context.Canceled) {
+		t.Fatalf("wrong error returned after cancellation: %v", err)
+	}
+}
+
+func listenUDPPair(t testing.TB) (c1, c2 *wasip1.UDPConn) {
+	laddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	c1, err := wasip1.ListenUDP("udp4", laddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c1.Close() })
+	c2, err = wasip1.ListenUDP("udp4", laddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c2.Close() })
+	return c1, c2
+}
+
+func TestPacketConnAllocs(t *testing.T) {
+	c1, c2 := listenUDPPair(t)
+	addr := c2.LocalAddr()
+	addrPort := c2.LocalAddrPort()
+	buf := make([]byte, 64)
+
+	tests := []struct {
+		scenario string
+		function func()
+	}{
+		{
+			scenario: "WriteTo",
+			function: func() {
+				if _, err := c1.WriteTo(buf, addr); err != nil {
+					t.Fatal(err)
+				}
+				if _, _, err := c2.ReadFromUDPAddrPort(buf); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+
+		{
+			scenario: "WriteToUDPAddrPort",
+			function: func() {
+				if _, err := c1.WriteToUDPAddrPort(buf, addrPort); err != nil {
+					t.Fatal(err)
+				}
+				if _, _, err := c2.ReadFromUDPAddrPort(buf); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			if allocs := testing.AllocsPerRun(100, test.function); allocs != 0 {
+				t.Errorf("wrong number of allocations: want=0 got=%g", allocs)
+			}
+		})
+	}
+}
+
+func BenchmarkPacketConn(b *testing.B) {
+	c1, c2 := listenUDPPair(b)
+	addr := c2.LocalAddr()
+	addrPort := c2.LocalAddrPort()
+	buf := make([]byte, 1024)
+
+	b.Run("WriteTo+ReadFrom", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(buf)))
+		for i := 0; i < b.N; i++ {
+			if _, err := c1.WriteTo(buf, addr); err != nil {
+				b.Fatal(err)
+			}
+			// ReadFrom must allocate the returned net.Addr.
+			if _, _, err := c2.ReadFrom(buf); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("WriteToUDPAddrPort+ReadFromUDPAddrPort", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(buf)))
+		for i := 0; i < b.N; i++ {
+			if _, err := c1.WriteToUDPAddrPort(buf, addrPort); err != nil {
+				b.Fatal(err)
+			}
+			if _, _, err := c2.ReadFromUDPAddrPort(buf); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestBuffers(t *testing.T) {
+	type buffersConn interface {
+		net.Conn
+		WriteBuffers(*net.Buffers) (int64, error)
+		ReadBuffers([][]byte) (int, error)
+	}
+
+	tests := []struct {
+		network string
+		connect func(*testing.T) (c1, c2 buffersConn)
+	}{
+		{
+			network: "tcp",
+			connect: func(t *testing.T) (c1, c2 buffersConn) {
+				l, err := wasip1.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer l.Close()
+				d, err := wasip1.DialTCP("tcp", nil, l.Addr().(*net.TCPAddr))
+				if err != nil {
+					t.Fatal(err)
+				}
+				a, err := l.AcceptTCP()
+				if err != nil {
+					d.Close()
+					t.Fatal(err)
+				}
+				return d, a
+			},
+		},
+
+		{
+			network: "unix",
+			connect: func(t *testing.T) (c1, c2 buffersConn) {
+				addr := &net.UnixAddr{Name: filepath.Join(t.TempDir(), "wasip1.sock"), Net: "unix"}
+				l, err := wasip1.ListenUnix("unix", addr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer l.Close()
+				d, err := wasip1.DialUnix("unix", nil, addr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				a, err := l.AcceptUnix()
+				if err != nil {
+					d.Close()
+					t.Fatal(err)
+				}
+				return d, a
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.network, func(t *testing.T) {
+			w, r := test.connect(t)
+			defer w.Close()
+			defer r.Close()
+
+			v := net.Buffers{[]byte("hello"), nil, []byte(", "), []byte("world!")}
+			n, err := w.WriteBuffers(&v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 13 {
+				t.Fatalf("wrong number of bytes written: want=13 got=%d", n)
+			}
+			if len(v) != 0 {
+				t.Fatalf("buffers were not consumed: %q", v)
+			}
+
+			a := make([]byte, 7)
+			b := make([]byte, 6)
+			for off := 0; off < 13; {
+				bufs := [][]byte{a, b}
+				switch {
+				case off >= len(a):
+					bufs = [][]byte{b[off-len(a):]}
+				default:
+					bufs[0] = a[off:]
+				}
+				n, err := r.ReadBuffers(bufs)
+				if err != nil {
+					t.Fatal(err)
+				}
+				off += n
+			}
+			if string(a) != "hello, " || string(b) != "world!" {
+				t.Fatalf("wrong data read: %q %q", a, b)
+			}
+
+			w.Close()
+			if _, err := r.ReadBuffers([][]byte{a, b}); err != io.EOF {
+				t.Fatalf("wrong error after closing the connection: want=%v got=%v", io.EOF, err)
+			}
+		})
+	}
+}
+
+func TestWriteBuffers(t *testing.T) {
+	l, err := wasip1.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	c1, err := wasip1.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+
+	c2, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+
+	// Dial and Listen return the connection types of the net package rather
+	// than wasip1.TCPConn, WriteBuffers must use vectored writes on them too.
+	for _, test := range []struct {
+		name string
+		w, r net.Conn
+	}{
+		{"dial", c1, c2},
+		{"accept", c2, c1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, ok := test.w.(*net.TCPConn); !ok {
+				t.Fatalf("wrong connection type: %T", test.w)
+			}
+
+			// Write more buffers than can be passed to the host at once.
+			var v net.Buffers
+			var want []byte
+			for i := 0; i < 100; i++ {
+				b := []byte(strconv.Itoa(i) + ",")
+				v = append(v, b)
+				want = append(want, b...)
+			}
+			n, err := wasip1.WriteBuffers(test.w, &v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(len(want)) {
+				t.Fatalf("wrong number of bytes written: want=%d got=%d", len(want), n)
+			}
+			if len(v) != 0 {
+				t.Fatalf("buffers were not consumed: %q", v)
+			}
+
+			b := make([]byte, len(want))
+			if _, err := io.ReadFull(test.r, b); err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != string(want) {
+				t.Fatalf("wrong data read: want=%q got=%q", want, b)
+			}
+		})
+	}
+}
+
+func TestTrackedListener(t *testing.T) {
+	lstn, err := wasip1.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := wasip1.NewTrackedListener(lstn, 1)
+	defer l.Close()
+
+	dial := func() net.Conn {
+		c, err := wasip1.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+
+	dial()
+	c1, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := l.ActiveConns(); n != 1 {
+		t.Fatalf("wrong number of active connections: want=1 got=%d", n)
+	}
+
+	// The connection limit prevents the second connection from being accepted
+	// until the first one is closed.
+	client := dial()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := l.AcceptContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wrong error accepting beyond the limit: %v", err)
+	}
+
+	if err := l.SetDeadline(time.Now().Add(10 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Accept(); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("wrong error accepting after the deadline: %v", err)
+	}
+	if err := l.SetDeadline(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	c1.Close()
+	c2, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c2.(interface{ NetConn() net.Conn }).NetConn().(*net.TCPConn); !ok {
+		t.Fatal("tracked connection does not expose the underlying connection")
+	}
+
+	// Shutdown closes the active connections when the context expires.
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := l.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wrong error returned by shutdown: %v", err)
+	}
+	if n := l.ActiveConns(); n != 0 {
+		t.Fatalf("wrong number of active connections: want=0 got=%d", n)
+	}
+	if _, err := client.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("connection was not closed by shutdown: %v", err)
+	}
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("wrong error accepting after shutdown: %v", err)
+	}
+}
+
+func TestTrackedListenerTemporaryError(t *testing.T) {
+	lstn := &flakyListener{
+		Listener: mustListen(t),
+		errors:   3,
+	}
+	l := wasip1.NewTrackedListener(lstn, 0)
+
+	c, err := wasip1.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// The temporary errors are retried instead of shutting down the listener.
+	a, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Close()
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown after close returned an error: %v", err)
+	}
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("wrong error accepting after close: %v", err)
+	}
+}
+
+func TestTrackedListenerAcceptError(t *testing.T) {
+	lstn := mustListen(t)
+	l := wasip1.NewTrackedListener(lstn, 0)
+	defer l.Close()
+
+	// Closing the underlying listener makes Accept fail permanently, the error
+	// is returned as is rather than wrapped a second time.
+	lstn.Close()
+	_, err := l.Accept()
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("wrong error type: %T", err)
+	}
+	if errors.As(opErr.Err, new(*net.OpError)) {
+		t.Fatalf("accept error was wrapped twice: %v", err)
+	}
+	if !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("wrong error accepting after closing the listener: %v", err)
+	}
+}
+
+func mustListen(t *testing.T) net.Listener {
+	l, err := wasip1.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+// flakyListener returns temporary errors from the first calls to Accept.
+type flakyListener struct {
+	net.Listener
+	errors int
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	if l.errors > 0 {
+		l.errors--
+		return nil, temporaryError{}
+	}
+	return l.Listener.Accept()
+}
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "temporary error" }
+func (temporaryError) Timeout() bool   { return false }
+func (temporaryError) Temporary() bool { return true }
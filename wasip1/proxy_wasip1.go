@@ -0,0 +1,227 @@
+//go:build wasip1
+
+package wasip1
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+)
+
+// NewProxyDialer returns a dialer establishing connections through the proxy
+// at proxyURL, using forward to connect to the proxy. When forward is nil, the
+// dial functions of this package are used.
+//
+// The supported schemes are "http" and "https" for proxies supporting the HTTP
+// CONNECT method, and "socks5" or "socks5h" for SOCKS5 proxies. Credentials
+// are taken from the user information of the URL. The certificates of https
+// proxies are verified with the roots returned by SystemCertPool.
+func NewProxyDialer(proxyURL *url.URL, forward proxy.ContextDialer) (proxy.ContextDialer, error) {
+	if forward == nil {
+		forward = new(Dialer)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https":
+		return &httpProxyDialer{proxyURL: proxyURL, forward: forward}, nil
+	case "socks5", "socks5h":
+		d, err := proxy.FromURL(proxyURL, contextDialer{forward})
+		if err != nil {
+			return nil, err
+		}
+		return d.(proxy.ContextDialer), nil
+	default:
+		return nil, fmt.Errorf("proxy: unsupported scheme: %q", proxyURL.Scheme)
+	}
+}
+
+// ProxyDialerFromEnvironment returns a dialer configured from the environment
+// variables HTTPS_PROXY, ALL_PROXY, and NO_PROXY (or their lowercase versions).
+//
+// The precedence is the same as the ProxyFromEnvironment function of the http
+// package: HTTPS_PROXY is used when it is set, and ALL_PROXY otherwise.
+// Addresses matching NO_PROXY, and all addresses when no proxy is configured,
+// are dialed directly with forward, or with the dial functions of this package
+// if forward is nil.
+//
+// The environment is read when the function is called.
+func ProxyDialerFromEnvironment(forward proxy.ContextDialer) proxy.ContextDialer {
+	if forward == nil {
+		forward = new(Dialer)
+	}
+	config := httpproxy.FromEnvironment()
+	if config.HTTPSProxy == "" {
+		config.HTTPSProxy = getenv("ALL_PROXY", "all_proxy")
+	}
+	return &envProxyDialer{
+		proxyFunc: config.ProxyFunc(),
+		forward:   forward,
+	}
+}
+
+func getenv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+type envProxyDialer struct {
+	proxyFunc func(*url.URL) (*url.URL, error)
+	forward   proxy.ContextDialer
+}
+
+func (d *envProxyDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+func (d *envProxyDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	proxyURL, err := d.proxyFunc(&url.URL{Scheme: "https", Host: address})
+	if err != nil {
+		return nil, dialErr(&netAddr{network, address}, err)
+	}
+	if proxyURL == nil {
+		return d.forward.DialContext(ctx, network, address)
+	}
+	proxyDialer, err := NewProxyDialer(proxyURL, d.forward)
+	if err != nil {
+		return nil, dialErr(&netAddr{network, address}, err)
+	}
+	return proxyDialer.DialContext(ctx, network, address)
+}
+
+// httpProxyDialer establishes TCP tunnels with the HTTP CONNECT method.
+type httpProxyDialer struct {
+	proxyURL *url.URL
+	forward  proxy.ContextDialer
+}
+
+func (d *httpProxyDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+func (d *httpProxyDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, unsupportedNetwork(network, address)
+	}
+
+	proxyAddr := d.proxyURL.Host
+	if d.proxyURL.Port() == "" {
+		port := "80"
+		if d.proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(d.proxyURL.Hostname(), port)
+	}
+
+	var config *tls.Config
+	if d.proxyURL.Scheme == "https" {
+		c, err := clientConfig(nil, d.proxyURL.Hostname())
+		if err != nil {
+			return nil, dialErr(&netAddr{network, address}, err)
+		}
+		config = c
+	}
+
+	conn, err := d.forward.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if config != nil {
+		conn = tls.Client(conn, config)
+	}
+	// Interrupt the exchange with the proxy if the context is canceled.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(aLongTimeAgo) })
+	tunnel, err := d.connect(conn, address)
+	if !stop() {
+		if err == nil {
+			tunnel.Close()
+		}
+		return nil, dialErr(&netAddr{network, address}, context.Cause(ctx))
+	}
+	if err != nil {
+		return nil, dialErr(&netAddr{network, address}, err)
+	}
+	return tunnel, nil
+}
+
+func (d *httpProxyDialer) connect(conn net.Conn, address string) (net.Conn, error) {
+	var req strings.Builder
+	fmt.Fprintf(&req, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n", address, address)
+	if user := d.proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		fmt.Fprintf(&req, "Proxy-Authorization: Basic %s\r\n", credentials)
+	}
+	req.WriteString("\r\n")
+
+	if _, err := conn.Write([]byte(req.String())); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	statusLine, err := textproto.NewReader(r).ReadLine()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := textproto.NewReader(r).ReadMIMEHeader(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// The status line has the form "HTTP/1.1 200 Connection established".
+	_, status, _ := strings.Cut(statusLine, " ")
+	code, _, _ := strings.Cut(status, " ")
+	if statusCode, err := strconv.Atoi(code); err != nil || statusCode != 200 {
+		conn.Close()
+		return nil, fmt.Errorf("proxy: CONNECT %s: %s", address, status)
+	}
+
+	if r.Buffered() > 0 {
+		// The proxy may have sent data from the remote peer along with the
+		// response, it must not be lost.
+		return &bufferedConn{Conn: conn, r: r}, nil
+	}
+	return conn, nil
+}
+
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	if c.r.Buffered() > 0 {
+		return c.r.Read(b)
+	}
+	return c.Conn.Read(b)
+}
+
+// contextDialer adapts a proxy.ContextDialer to the proxy.Dialer interface
+// expected by the golang.org/x/net/proxy package.
+type contextDialer struct{ proxy.ContextDialer }
+
+func (d contextDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+var (
+	_ proxy.ContextDialer = (*Dialer)(nil)
+	_ proxy.ContextDialer = (*httpProxyDialer)(nil)
+	_ proxy.ContextDialer = (*envProxyDialer)(nil)
+)
@@ -0,0 +1,213 @@
+//go:build wasip1
+
+package wasip1_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stealthrocket/net/wasip1"
+)
+
+func TestProxyDialer(t *testing.T) {
+	echo := listenEcho(t)
+
+	tests := []struct {
+		scheme string
+		serve  func(net.Conn) (net.Conn, error)
+	}{
+		{
+			scheme: "http",
+			serve:  serveHTTPConnect,
+		},
+
+		{
+			scheme: "socks5",
+			serve:  serveSOCKS5,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scheme, func(t *testing.T) {
+			proxyAddr := listenProxy(t, test.serve)
+			proxyURL := &url.URL{Scheme: test.scheme, Host: proxyAddr}
+
+			d, err := wasip1.NewProxyDialer(proxyURL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testProxyDialer(t, d, echo)
+		})
+	}
+
+	t.Run("environment", func(t *testing.T) {
+		proxyAddr := listenProxy(t, serveHTTPConnect)
+		t.Setenv("HTTPS_PROXY", "")
+		t.Setenv("ALL_PROXY", "http://"+proxyAddr)
+		t.Setenv("NO_PROXY", "")
+		testProxyDialer(t, wasip1.ProxyDialerFromEnvironment(nil), echo)
+	})
+}
+
+func testProxyDialer(t *testing.T, d interface {
+	DialContext(context.Context, string, string) (net.Conn, error)
+}, address string) {
+	// Use a name which is not a loopback address, otherwise the proxy would be
+	// bypassed by the environment configuration.
+	_, port, _ := net.SplitHostPort(address)
+	c, err := d.DialContext(context.Background(), "tcp", net.JoinHostPort("echo.test", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := io.WriteString(c, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 5)
+	if _, err := io.ReadFull(c, b); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello" {
+		t.Fatalf("wrong data received through the proxy: %q", b)
+	}
+}
+
+func listenEcho(t *testing.T) string {
+	l, err := wasip1.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				io.Copy(c, c)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+// listenProxy starts a proxy server which forwards all connections to the echo
+// server, regardless of the requested address.
+func listenProxy(t *testing.T, serve func(net.Conn) (net.Conn, error)) string {
+	l, err := wasip1.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				upstream, err := serve(c)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				go io.Copy(upstream, c)
+				io.Copy(c, upstream)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func dialUpstream(address string) (net.Conn, error) {
+	// The test resolves all names to the loopback address.
+	_, port, _ := net.SplitHostPort(address)
+	return wasip1.Dial("tcp", net.JoinHostPort("127.0.0.1", port))
+}
+
+func serveHTTPConnect(c net.Conn) (net.Conn, error) {
+	req, err := http.ReadRequest(bufio.NewReader(c))
+	if err != nil {
+		return nil, err
+	}
+	if req.Method != http.MethodConnect {
+		io.WriteString(c, "HTTP/1.1 405 Method Not Allowed\r\n\r\n")
+		return nil, io.ErrUnexpectedEOF
+	}
+	upstream, err := dialUpstream(req.Host)
+	if err != nil {
+		io.WriteString(c, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+		return nil, err
+	}
+	io.WriteString(c, "HTTP/1.1 200 Connection established\r\n\r\n")
+	return upstream, nil
+}
+
+func serveSOCKS5(c net.Conn) (net.Conn, error) {
+	// Greeting: version, number of methods, methods.
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(c, hdr); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(c, make([]byte, hdr[1])); err != nil {
+		return nil, err
+	}
+	if _, err := c.Write([]byte{5, 0}); err != nil { // no authentication
+		return nil, err
+	}
+
+	// Request: version, command, reserved, address type, address, port.
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(c, req); err != nil {
+		return nil, err
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		addr := make([]byte, 4)
+		if _, err := io.ReadFull(c, addr); err != nil {
+			return nil, err
+		}
+		host = net.IP(addr).String()
+	case 3:
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(c, n); err != nil {
+			return nil, err
+		}
+		name := make([]byte, n[0])
+		if _, err := io.ReadFull(c, name); err != nil {
+			return nil, err
+		}
+		host = string(name)
+	default:
+		c.Write([]byte{5, 8, 0, 1, 0, 0, 0, 0, 0, 0}) // address type not supported
+		return nil, io.ErrUnexpectedEOF
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(c, port); err != nil {
+		return nil, err
+	}
+
+	address := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+	upstream, err := dialUpstream(address)
+	if err != nil {
+		c.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0}) // general failure
+		return nil, err
+	}
+	if _, err := c.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		upstream.Close()
+		return nil, err
+	}
+	return upstream, nil
+}
@@ -0,0 +1,35 @@
+//go:build wasip1
+
+package wasip1
+
+import (
+	"context"
+	"net"
+)
+
+// LookupHost looks up the given host and returns a slice of its addresses.
+//
+// The function resolves names with the same method as the dial functions of
+// this package, which is either the pure Go resolver, or sock_getaddrinfo when
+// the program was compiled with the getaddrinfo build tag. It is useful to
+// configure libraries which perform name resolution before dialing, and would
+// otherwise use net.LookupHost.
+func LookupHost(ctx context.Context, host string) ([]string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []string{host}, nil
+	}
+	addrs, err := lookupAddr(ctx, "dial", "tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		a := addr.(*net.TCPAddr)
+		if a.Zone != "" {
+			hosts = append(hosts, a.IP.String()+"%"+a.Zone)
+		} else {
+			hosts = append(hosts, a.IP.String())
+		}
+	}
+	return hosts, nil
+}
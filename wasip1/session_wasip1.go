@@ -0,0 +1,413 @@
+//go:build wasip1
+
+package wasip1
+
+import (
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSessionBacklog   = 128
+	defaultSessionQueueSize = 64
+
+	maxDatagramSize = 65535
+)
+
+// SessionConfig contains options for creating a SessionListener.
+//
+// The zero-value is a valid configuration which uses default values for the
+// backlog and queue sizes, and never expires idle sessions.
+type SessionConfig struct {
+	// IdleTimeout is the duration after which a session which did not receive
+	// or send any datagram is closed. Zero means sessions never expire.
+	IdleTimeout time.Duration
+
+	// Backlog is the maximum number of sessions waiting to be accepted.
+	// Datagrams from new peers are dropped when the backlog is full.
+	Backlog int
+
+	// QueueSize is the maximum number of datagrams buffered for each session.
+	// Datagrams are dropped when the application does not read them fast
+	// enough and the queue is full, like they would be by the network stack.
+	QueueSize int
+}
+
+// Listen announces on the local UDP address and returns a listener which
+// demultiplexes incoming datagrams into sessions.
+//
+// The network must be one of "udp", "udp4" or "udp6".
+func (sc *SessionConfig) Listen(network, address string) (*SessionListener, error) {
+	switch network {
+	case "udp", "udp4", "udp6":
+	default:
+		return nil, unsupportedNetwork(network, address)
+	}
+	c, err := ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+	config := *sc
+	if config.Backlog <= 0 {
+		config.Backlog = defaultSessionBacklog
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultSessionQueueSize
+	}
+	l := &SessionListener{
+		conn:     c.(*UDPConn).packetConn,
+		config:   config,
+		accept:   make(chan *sessionConn, config.Backlog),
+		done:     make(chan struct{}),
+		sessions: make(map[netip.AddrPort]*sessionConn),
+	}
+	go l.serve()
+	return l, nil
+}
+
+// ListenUDPSessions is like ListenPacket but it returns a net.Listener where
+// each remote address sending datagrams to the local address is represented
+// by its own net.Conn.
+//
+// This is useful to serve protocols designed on top of UDP which expect a
+// connection-oriented API, such as DTLS or KCP.
+func ListenUDPSessions(network, address string) (*SessionListener, error) {
+	return (&SessionConfig{}).Listen(network, address)
+}
+
+// SessionListener is a net.Listener accepting UDP sessions.
+//
+// Closing the listener stops accepting new sessions but does not interrupt the
+// sessions that were already accepted; the underlying socket is closed when
+// the listener and all its sessions have been closed.
+type SessionListener struct {
+	conn   *packetConn
+	config SessionConfig
+	accept chan *sessionConn
+	done   chan struct{}
+	once   sync.Once
+
+	mutex    sync.Mutex
+	sessions map[netip.AddrPort]*sessionConn
+	closed   bool
+	err      error
+}
+
+// Accept waits for and returns the next session to the listener.
+func (l *SessionListener) Accept() (net.Conn, error) {
+	select {
+	case s := <-l.accept:
+		return s, nil
+	case <-l.done:
+		l.mutex.Lock()
+		err := l.err
+		l.mutex.Unlock()
+		return nil, &net.OpError{
+			Op:   "accept",
+			Net:  l.conn.laddr.Network(),
+			Addr: l.conn.laddr,
+			Err:  err,
+		}
+	}
+}
+
+// Close stops accepting new sessions. Sessions which were received but not
+// yet accepted are closed.
+func (l *SessionListener) Close() error {
+	l.shutdown(net.ErrClosed)
+	return nil
+}
+
+// Addr returns the local address of the listener.
+func (l *SessionListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+func (l *SessionListener) shutdown(err error) {
+	l.once.Do(func() {
+		l.mutex.Lock()
+		l.closed, l.err = true, err
+		close(l.done)
+		l.mutex.Unlock()
+
+		for {
+			select {
+			case s := <-l.accept:
+				s.closeWithError(io.EOF)
+			default:
+				l.mutex.Lock()
+				l.release()
+				l.mutex.Unlock()
+				return
+			}
+		}
+	})
+}
+
+// release closes the socket if the listener and all the sessions are closed;
+// it must be called with the listener mutex held.
+func (l *SessionListener) release() {
+	if l.closed && len(l.sessions) == 0 && l.sessions != nil {
+		l.sessions = nil
+		l.conn.Close()
+	}
+}
+
+func (l *SessionListener) serve() {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		// The buffer has the size of the largest UDP payload, datagrams are
+		// never truncated.
+		n, _, _, addr, err := l.conn.ReadMsgUDPAddrPort(buf, nil)
+		if err != nil {
+			if err == io.EOF && addr.IsValid() {
+				continue // zero-length datagram
+			}
+			// io.EOF without a source address means that the socket was
+			// shut down, it is reported as such to the sessions.
+			l.shutdown(err)
+			l.closeSessions(io.EOF)
+			return
+		}
+		l.dispatch(addr, buf[:n])
+	}
+}
+
+func (l *SessionListener) dispatch(addr netip.AddrPort, b []byte) {
+	l.mutex.Lock()
+	s := l.sessions[addr]
+	if s == nil {
+		if l.closed {
+			l.mutex.Unlock()
+			return
+		}
+		s = l.newSession(addr)
+		select {
+		case l.accept <- s:
+			l.sessions[addr] = s
+		default:
+			// The backlog is full, drop the datagram and let the peer retry.
+			l.mutex.Unlock()
+			return
+		}
+	}
+	l.mutex.Unlock()
+	s.push(append([]byte(nil), b...))
+}
+
+func (l *SessionListener) newSession(addr netip.AddrPort) *sessionConn {
+	s := &sessionConn{
+		listener: l,
+		raddr:    net.UDPAddrFromAddrPort(addr),
+		addrPort: addr,
+		queue:    make(chan []byte, l.config.QueueSize),
+		done:     make(chan struct{}),
+	}
+	if l.config.IdleTimeout > 0 {
+		s.idle = time.AfterFunc(l.config.IdleTimeout, func() {
+			s.closeWithError(io.EOF)
+		})
+	}
+	return s
+}
+
+func (l *SessionListener) closeSessions(err error) {
+	l.mutex.Lock()
+	sessions := make([]*sessionConn, 0, len(l.sessions))
+	for _, s := range l.sessions {
+		sessions = append(sessions, s)
+	}
+	l.mutex.Unlock()
+
+	for _, s := range sessions {
+		s.closeWithError(err)
+	}
+}
+
+func (l *SessionListener) remove(s *sessionConn) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.sessions[s.addrPort] == s {
+		delete(l.sessions, s.addrPort)
+	}
+	l.release()
+}
+
+type sessionConn struct {
+	listener *SessionListener
+	raddr    *net.UDPAddr
+	addrPort netip.AddrPort
+	queue    chan []byte
+	done     chan struct{}
+	idle     *time.Timer
+	once     sync.Once
+	err      error
+
+	readDeadline  deadline
+	writeDeadline deadline
+}
+
+func (s *sessionConn) push(b []byte) {
+	select {
+	case s.queue <- b:
+		s.touch()
+	default:
+		// The application is not keeping up with the peer, drop the datagram.
+	}
+}
+
+func (s *sessionConn) touch() {
+	if s.idle != nil {
+		s.idle.Reset(s.listener.config.IdleTimeout)
+	}
+}
+
+func (s *sessionConn) closeWithError(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+		if s.idle != nil {
+			s.idle.Stop()
+		}
+		s.listener.remove(s)
+	})
+}
+
+func (s *sessionConn) opError(op string, err error) error {
+	return &net.OpError{
+		Op:     op,
+		Net:    s.raddr.Network(),
+		Source: s.LocalAddr(),
+		Addr:   s.raddr,
+		Err:    err,
+	}
+}
+
+// Read reads the next datagram of the session into b. If b is too small to
+// hold the datagram, the first len(b) bytes are returned along with
+// io.ErrShortBuffer, and the rest of the datagram is discarded, which is the
+// equivalent of the MSG_TRUNC flag of the socket API.
+func (s *sessionConn) Read(b []byte) (int, error) {
+	select {
+	case p := <-s.queue:
+		return readDatagram(b, p)
+	default:
+	}
+	select {
+	case p := <-s.queue:
+		return readDatagram(b, p)
+	case <-s.done:
+		if s.err == io.EOF {
+			return 0, io.EOF
+		}
+		return 0, s.opError("read", s.err)
+	case <-s.readDeadline.wait():
+		return 0, s.opError("read", os.ErrDeadlineExceeded)
+	}
+}
+
+func readDatagram(b, p []byte) (int, error) {
+	n := copy(b, p)
+	if n < len(p) {
+		return n, io.ErrShortBuffer
+	}
+	return n, nil
+}
+
+func (s *sessionConn) Write(b []byte) (int, error) {
+	select {
+	case <-s.done:
+		return 0, s.opError("write", net.ErrClosed)
+	case <-s.writeDeadline.wait():
+		return 0, s.opError("write", os.ErrDeadlineExceeded)
+	default:
+	}
+	n, _, err := s.listener.conn.WriteMsgUDPAddrPort(b, nil, s.addrPort)
+	if err != nil {
+		return n, s.opError("write", err)
+	}
+	s.touch()
+	return n, nil
+}
+
+func (s *sessionConn) Close() error {
+	s.closeWithError(net.ErrClosed)
+	return nil
+}
+
+func (s *sessionConn) LocalAddr() net.Addr {
+	return s.listener.conn.LocalAddr()
+}
+
+func (s *sessionConn) RemoteAddr() net.Addr {
+	return s.raddr
+}
+
+func (s *sessionConn) SetDeadline(t time.Time) error {
+	s.readDeadline.set(t)
+	s.writeDeadline.set(t)
+	return nil
+}
+
+func (s *sessionConn) SetReadDeadline(t time.Time) error {
+	s.readDeadline.set(t)
+	return nil
+}
+
+func (s *sessionConn) SetWriteDeadline(t time.Time) error {
+	s.writeDeadline.set(t)
+	return nil
+}
+
+// deadline implements the deadline semantics of net.Conn for connections that
+// are not backed by a file descriptor. The channel returned by wait is closed
+// when the deadline expires.
+type deadline struct {
+	mutex sync.Mutex
+	timer *time.Timer
+	ch    chan struct{}
+}
+
+func (d *deadline) set(t time.Time) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.ch == nil {
+		d.ch = make(chan struct{})
+	}
+	if d.timer != nil && !d.timer.Stop() {
+		// The timer already fired or is about to close the channel, wait for
+		// it to be closed before replacing it.
+		<-d.ch
+	}
+	d.timer = nil
+
+	select {
+	case <-d.ch:
+		d.ch = make(chan struct{})
+	default:
+	}
+
+	if t.IsZero() {
+		return
+	}
+	if timeout := time.Until(t); timeout <= 0 {
+		close(d.ch)
+	} else {
+		ch := d.ch
+		d.timer = time.AfterFunc(timeout, func() { close(ch) })
+	}
+}
+
+func (d *deadline) wait() <-chan struct{} {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.ch == nil {
+		d.ch = make(chan struct{})
+	}
+	return d.ch
+}
@@ -0,0 +1,91 @@
+//go:build wasip1
+
+package wasip1_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/net/wasip1"
+)
+
+func TestSessionListener(t *testing.T) {
+	config := &wasip1.SessionConfig{IdleTimeout: 100 * time.Millisecond}
+
+	l, err := config.Listen("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	clients := make([]net.Conn, 2)
+	for i := range clients {
+		c, err := wasip1.Dial("udp", l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		clients[i] = c
+
+		if _, err := c.Write([]byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := l.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+
+		if s.RemoteAddr().String() != c.LocalAddr().String() {
+			t.Fatalf("session has the wrong remote address: want=%s got=%s", c.LocalAddr(), s.RemoteAddr())
+		}
+
+		b := make([]byte, 32)
+		n, err := s.Read(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b[:n]) != "hello" {
+			t.Fatalf("wrong datagram received: %q", b[:n])
+		}
+
+		if _, err := s.Write([]byte("world")); err != nil {
+			t.Fatal(err)
+		}
+		n, err = c.Read(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b[:n]) != "world" {
+			t.Fatalf("wrong datagram received: %q", b[:n])
+		}
+
+		// Datagrams larger than the read buffer are truncated and reported.
+		if _, err := c.Write([]byte("hello world")); err != nil {
+			t.Fatal(err)
+		}
+		n, err = s.Read(b[:5])
+		if err != io.ErrShortBuffer {
+			t.Fatalf("truncated datagram returned the wrong error: want=%v got=%v", io.ErrShortBuffer, err)
+		}
+		if string(b[:n]) != "hello" {
+			t.Fatalf("wrong datagram received: %q", b[:n])
+		}
+
+		s.SetReadDeadline(time.Now().Add(time.Hour))
+		if _, err := s.Read(b); err != io.EOF {
+			t.Fatalf("idle session returned the wrong error: want=%v got=%v", io.EOF, err)
+		}
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("closed listener returned the wrong error: want=%v got=%v", net.ErrClosed, err)
+	}
+}
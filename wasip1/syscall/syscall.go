@@ -0,0 +1,21 @@
+// Package syscall exposes the low-level socket functions that the wasip1
+// package is built on.
+//
+// The functions of this package are thin wrappers around the socket extensions
+// implemented by WasmEdge v0.12+ and wasi-go (sock_open, sock_bind,
+// sock_connect, sock_recv_from, etc...). They follow the naming conventions of
+// the standard syscall package, and operate on file descriptors that can be
+// converted to network connections with wasip1.FileConn, wasip1.FileListener,
+// or wasip1.FilePacketConn.
+//
+// Most programs should use the higher level functions of the wasip1 package.
+// This package is intended for libraries which need to build features that
+// the wasip1 package does not cover, such as setting socket options at
+// arbitrary levels or peeking at incoming datagrams.
+//
+// Unlike the wasip1 package, the functions of this package do not retry on
+// EAGAIN; callers that need blocking operations must use the syscall.RawConn
+// of a connection to integrate with the Go runtime network poller.
+//
+// When compiling to other targets than GOOS=wasip1, this package is empty.
+package syscall
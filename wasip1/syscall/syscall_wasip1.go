@@ -0,0 +1,748 @@
+//go:build wasip1
+
+package syscall
+
+import (
+	"encoding/binary"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// Address families.
+const (
+	AF_UNSPEC = iota
+	AF_INET
+	AF_INET6
+	AF_UNIX
+)
+
+// Socket types.
+const (
+	SOCK_ANY = iota
+	SOCK_DGRAM
+	SOCK_STREAM
+)
+
+// Socket option levels.
+const (
+	SOL_SOCKET = iota
+)
+
+// Options at the SOL_SOCKET level.
+const (
+	SO_REUSEADDR = iota
+	SO_TYPE
+	SO_ERROR
+	SO_DONTROUTE
+	SO_BROADCAST
+	SO_SNDBUF
+	SO_RCVBUF
+	SO_KEEPALIVE
+	SO_OOBINLINE
+	SO_LINGER
+	SO_RCVLOWAT
+	SO_RCVTIMEO
+	SO_SNDTIMEO
+	SO_ACCEPTCONN
+)
+
+// Flags of the hints passed to Getaddrinfo.
+const (
+	AI_PASSIVE = 1 << iota
+	AI_CANONNAME
+	AI_NUMERICHOST
+	AI_NUMERICSERV
+	AI_V4MAPPED
+	AI_ALL
+	AI_ADDRCONFIG
+)
+
+// Protocols.
+const (
+	IPPROTO_IP = iota
+	IPPROTO_TCP
+	IPPROTO_UDP
+)
+
+// Input flags of Recvfrom, RecvmsgBuffers, and RecvBuffers.
+const (
+	MSG_PEEK = 1 << iota
+	MSG_WAITALL
+)
+
+// Output flags of RecvmsgBuffers and RecvBuffers.
+const (
+	MSG_TRUNC = 1 << iota
+)
+
+// Values of the how argument of Shutdown.
+const (
+	SHUT_RD = 1 << iota
+	SHUT_WR
+	SHUT_RDWR = SHUT_RD | SHUT_WR
+)
+
+// Sockaddr is an interface implemented by the socket address types of this
+// package: *SockaddrInet4, *SockaddrInet6, and *SockaddrUnix.
+type Sockaddr interface {
+	sockaddr() (unsafe.Pointer, error)
+	sockaddrAny(*RawSockaddrAny) error
+	sockport() int
+}
+
+// SockaddrInet4 is an IPv4 socket address.
+type SockaddrInet4 struct {
+	Port int
+	Addr [4]byte
+	raw  addressBuffer
+}
+
+func (s *SockaddrInet4) sockaddr() (unsafe.Pointer, error) {
+	s.raw.bufLen = 4
+	s.raw.buf = uintptr32(uintptr(unsafe.Pointer(&s.Addr)))
+	return unsafe.Pointer(&s.raw), nil
+}
+
+func (s *SockaddrInet4) sockaddrAny(rsa *RawSockaddrAny) error {
+	rsa.Family = AF_INET
+	copy(rsa.Addr[:], s.Addr[:])
+	return nil
+}
+
+func (s *SockaddrInet4) sockport() int {
+	return s.Port
+}
+
+// SockaddrInet6 is an IPv6 socket address.
+//
+// The socket extensions have no representation for the scope ID and flow info
+// of IPv6 addresses, so unlike its counterpart in the standard syscall package
+// the type has no ZoneId field.
+type SockaddrInet6 struct {
+	Port int
+	Addr [16]byte
+	raw  addressBuffer
+}
+
+func (s *SockaddrInet6) sockaddr() (unsafe.Pointer, error) {
+	s.raw.bufLen = 16
+	s.raw.buf = uintptr32(uintptr(unsafe.Pointer(&s.Addr)))
+	return unsafe.Pointer(&s.raw), nil
+}
+
+func (s *SockaddrInet6) sockaddrAny(rsa *RawSockaddrAny) error {
+	rsa.Family = AF_INET6
+	copy(rsa.Addr[:], s.Addr[:])
+	return nil
+}
+
+func (s *SockaddrInet6) sockport() int {
+	return s.Port
+}
+
+// SockaddrUnix is a unix socket address.
+type SockaddrUnix struct {
+	Name string
+	raw  RawSockaddrAny
+	buf  addressBuffer
+}
+
+func (s *SockaddrUnix) sockaddr() (unsafe.Pointer, error) {
+	if err := s.sockaddrAny(&s.raw); err != nil {
+		return nil, err
+	}
+	s.buf.bufLen = uint32(unsafe.Sizeof(s.raw))
+	s.buf.buf = uintptr32(uintptr(unsafe.Pointer(&s.raw)))
+	return unsafe.Pointer(&s.buf), nil
+}
+
+func (s *SockaddrUnix) sockaddrAny(rsa *RawSockaddrAny) error {
+	if len(s.Name) >= len(rsa.Addr)-1 {
+		return syscall.EINVAL
+	}
+	rsa.Family = AF_UNIX
+	copy(rsa.Addr[:], s.Name)
+	rsa.Addr[len(s.Name)] = 0
+	return nil
+}
+
+func (s *SockaddrUnix) sockport() int {
+	return 0
+}
+
+type uintptr32 = uint32
+type size = uint32
+
+type addressBuffer struct {
+	buf    uintptr32
+	bufLen size
+}
+
+// RawSockaddrAny is the representation of socket addresses exchanged with the
+// sock_recv_from and sock_send_to host functions: the address family followed
+// by the address bytes, which are the 4 or 16 bytes of IP addresses, or the
+// null-terminated path of unix sockets.
+type RawSockaddrAny struct {
+	Family uint16
+	Addr   [126]byte
+}
+
+type iovec struct {
+	ptr uintptr32
+	len uint32
+}
+
+//go:wasmimport wasi_snapshot_preview1 sock_open
+//go:noescape
+func sock_open(af int32, socktype int32, fd unsafe.Pointer) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_bind
+//go:noescape
+func sock_bind(fd int32, addr unsafe.Pointer, port uint32) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_listen
+//go:noescape
+func sock_listen(fd int32, backlog int32) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_accept
+//go:noescape
+func sock_accept(fd int32, flags int32, newfd unsafe.Pointer) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_connect
+//go:noescape
+func sock_connect(fd int32, addr unsafe.Pointer, port uint32) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_getsockopt
+//go:noescape
+func sock_getsockopt(fd int32, level uint32, name uint32, value unsafe.Pointer, valueLen uint32) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_setsockopt
+//go:noescape
+func sock_setsockopt(fd int32, level uint32, name uint32, value unsafe.Pointer, valueLen uint32) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_getlocaladdr
+//go:noescape
+func sock_getlocaladdr(fd int32, addr unsafe.Pointer, port unsafe.Pointer) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_getpeeraddr
+//go:noescape
+func sock_getpeeraddr(fd int32, addr unsafe.Pointer, port unsafe.Pointer) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_recv_from
+//go:noescape
+func sock_recv_from(
+	fd int32,
+	iovs unsafe.Pointer,
+	iovsCount int32,
+	addr unsafe.Pointer,
+	iflags int32,
+	port unsafe.Pointer,
+	nread unsafe.Pointer,
+	oflags unsafe.Pointer,
+) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_send_to
+//go:noescape
+func sock_send_to(
+	fd int32,
+	iovs unsafe.Pointer,
+	iovsCount int32,
+	addr unsafe.Pointer,
+	port int32,
+	flags int32,
+	nwritten unsafe.Pointer,
+) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_getaddrinfo
+//go:noescape
+func sock_getaddrinfo(
+	node unsafe.Pointer,
+	nodeLen uint32,
+	service unsafe.Pointer,
+	serviceLen uint32,
+	hints unsafe.Pointer,
+	res unsafe.Pointer,
+	maxResLen uint32,
+	resLen unsafe.Pointer,
+) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_recv
+//go:noescape
+func sock_recv(
+	fd int32,
+	iovs unsafe.Pointer,
+	iovsCount int32,
+	iflags int32,
+	nread unsafe.Pointer,
+	oflags unsafe.Pointer,
+) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_send
+//go:noescape
+func sock_send(
+	fd int32,
+	iovs unsafe.Pointer,
+	iovsCount int32,
+	flags int32,
+	nwritten unsafe.Pointer,
+) syscall.Errno
+
+//go:wasmimport wasi_snapshot_preview1 sock_shutdown
+func sock_shutdown(fd, how int32) syscall.Errno
+
+// Socket creates a socket of the given address family and type, and returns
+// its file descriptor. The protocol is chosen by the runtime, the argument only
+// exists for compatibility with the standard syscall package.
+func Socket(domain, typ, proto int) (fd int, err error) {
+	var newfd int32
+	errno := sock_open(int32(domain), int32(typ), unsafe.Pointer(&newfd))
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(newfd), nil
+}
+
+// Bind assigns the address sa to the socket fd.
+func Bind(fd int, sa Sockaddr) error {
+	rawaddr, err := sa.sockaddr()
+	if err != nil {
+		return err
+	}
+	errno := sock_bind(int32(fd), rawaddr, uint32(sa.sockport()))
+	runtime.KeepAlive(sa)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Listen marks the socket fd as accepting connections.
+func Listen(fd int, backlog int) error {
+	if errno := sock_listen(int32(fd), int32(backlog)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Accept accepts a connection on the listening socket fd, and returns the file
+// descriptor of the new socket and the address of the peer.
+//
+// The new socket inherits the non-blocking mode of fd. The returned address is
+// nil if the runtime cannot determine the peer address.
+func Accept(fd int) (nfd int, sa Sockaddr, err error) {
+	var newfd int32
+	errno := sock_accept(int32(fd), 0, unsafe.Pointer(&newfd))
+	if errno != 0 {
+		return -1, nil, errno
+	}
+	sa, _ = Getpeername(int(newfd))
+	return int(newfd), sa, nil
+}
+
+// Connect connects the socket fd to the address sa.
+//
+// Non-blocking stream sockets return EINPROGRESS while the connection is being
+// established; the result can then be retrieved with the SO_ERROR option when
+// the socket becomes writable.
+func Connect(fd int, sa Sockaddr) error {
+	rawaddr, err := sa.sockaddr()
+	if err != nil {
+		return err
+	}
+	errno := sock_connect(int32(fd), rawaddr, uint32(sa.sockport()))
+	runtime.KeepAlive(sa)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Recvfrom receives a message from the socket fd into p, and returns the
+// number of bytes read and the address of the sender.
+func Recvfrom(fd int, p []byte, flags int) (n int, from Sockaddr, err error) {
+	n, _, from, err = RecvmsgBuffers(fd, [][]byte{p}, flags)
+	return n, from, err
+}
+
+// Sendto sends p to the address to on the socket fd. The address may be nil
+// on connected sockets.
+func Sendto(fd int, p []byte, flags int, to Sockaddr) error {
+	_, err := SendmsgBuffers(fd, [][]byte{p}, to, flags)
+	return err
+}
+
+// RecvfromAny is like Recvfrom but writes the address of the sender to from and
+// returns its port and the output flags. Unlike Recvfrom, the function does
+// not allocate memory, which makes it suitable for reading datagrams on hot
+// code paths.
+func RecvfromAny(fd int, p []byte, flags int, from *RawSockaddrAny) (n, port, recvflags int, err error) {
+	iov := iovec{
+		ptr: uintptr32(uintptr(unsafe.Pointer(unsafe.SliceData(p)))),
+		len: uint32(len(p)),
+	}
+	n, port, recvflags, err = recvfrom(fd, unsafe.Pointer(&iov), 1, flags, from)
+	runtime.KeepAlive(p)
+	return
+}
+
+// SendtoAny is like Sendto but takes the destination as a RawSockaddrAny and a
+// port, and returns the number of bytes written. The address may be the zero
+// value on connected sockets. Like RecvfromAny, the function does not allocate
+// memory.
+func SendtoAny(fd int, p []byte, flags int, to *RawSockaddrAny, port int) (n int, err error) {
+	iov := iovec{
+		ptr: uintptr32(uintptr(unsafe.Pointer(unsafe.SliceData(p)))),
+		len: uint32(len(p)),
+	}
+	n, err = sendto(fd, unsafe.Pointer(&iov), 1, flags, to, port)
+	runtime.KeepAlive(p)
+	return
+}
+
+// RecvmsgBuffers receives a message from the socket fd, scattering it across
+// buffers. It returns the number of bytes read, the output flags (such as
+// MSG_TRUNC), and the address of the sender.
+//
+// The input flags are a combination of MSG_PEEK and MSG_WAITALL.
+func RecvmsgBuffers(fd int, buffers [][]byte, flags int) (n, recvflags int, from Sockaddr, err error) {
+	var stack [8]iovec
+	iovs := makeIovecs(stack[:0], buffers)
+	var rsa RawSockaddrAny
+	n, port, recvflags, err := recvfrom(fd, unsafe.Pointer(unsafe.SliceData(iovs)), len(iovs), flags, &rsa)
+	runtime.KeepAlive(buffers)
+	if err != nil {
+		return n, recvflags, nil, err
+	}
+	from, _ = anyToSockaddr(&rsa, uint32(port))
+	return n, recvflags, from, nil
+}
+
+// SendmsgBuffers sends the concatenation of buffers to the address to on the
+// socket fd, and returns the number of bytes written. The address may be nil
+// on connected sockets.
+func SendmsgBuffers(fd int, buffers [][]byte, to Sockaddr, flags int) (n int, err error) {
+	var rsa RawSockaddrAny
+	var port int
+	if to != nil {
+		if err := to.sockaddrAny(&rsa); err != nil {
+			return 0, err
+		}
+		port = to.sockport()
+	}
+	var stack [8]iovec
+	iovs := makeIovecs(stack[:0], buffers)
+	n, err = sendto(fd, unsafe.Pointer(unsafe.SliceData(iovs)), len(iovs), flags, &rsa, port)
+	runtime.KeepAlive(buffers)
+	return n, err
+}
+
+// RecvBuffers receives data from the connected socket fd, scattering it across
+// buffers with a single call to sock_recv. It returns the number of bytes read
+// and the output flags.
+//
+// The input flags are a combination of MSG_PEEK and MSG_WAITALL.
+func RecvBuffers(fd int, buffers [][]byte, flags int) (n, recvflags int, err error) {
+	var stack [8]iovec
+	iovs := makeIovecs(stack[:0], buffers)
+	var nread, oflags int32
+	errno := sock_recv(
+		int32(fd),
+		unsafe.Pointer(unsafe.SliceData(iovs)),
+		int32(len(iovs)),
+		int32(flags),
+		unsafe.Pointer(&nread),
+		unsafe.Pointer(&oflags),
+	)
+	runtime.KeepAlive(buffers)
+	if errno != 0 {
+		return int(nread), int(oflags), errno
+	}
+	return int(nread), int(oflags), nil
+}
+
+// SendBuffers sends the concatenation of buffers on the connected socket fd
+// with a single call to sock_send, and returns the number of bytes written.
+func SendBuffers(fd int, buffers [][]byte, flags int) (n int, err error) {
+	var stack [8]iovec
+	iovs := makeIovecs(stack[:0], buffers)
+	var nwritten int32
+	errno := sock_send(
+		int32(fd),
+		unsafe.Pointer(unsafe.SliceData(iovs)),
+		int32(len(iovs)),
+		int32(flags),
+		unsafe.Pointer(&nwritten),
+	)
+	runtime.KeepAlive(buffers)
+	if errno != 0 {
+		return int(nwritten), errno
+	}
+	return int(nwritten), nil
+}
+
+func recvfrom(fd int, iovs unsafe.Pointer, iovsCount, flags int, from *RawSockaddrAny) (n, port, recvflags int, err error) {
+	addrBuf := addressBuffer{
+		buf:    uintptr32(uintptr(unsafe.Pointer(from))),
+		bufLen: uint32(unsafe.Sizeof(*from)),
+	}
+	var nread, rport, oflags int32
+	errno := sock_recv_from(
+		int32(fd),
+		iovs,
+		int32(iovsCount),
+		unsafe.Pointer(&addrBuf),
+		int32(flags),
+		unsafe.Pointer(&rport),
+		unsafe.Pointer(&nread),
+		unsafe.Pointer(&oflags),
+	)
+	if errno != 0 {
+		return int(nread), int(rport), int(oflags), errno
+	}
+	return int(nread), int(rport), int(oflags), nil
+}
+
+func sendto(fd int, iovs unsafe.Pointer, iovsCount, flags int, to *RawSockaddrAny, port int) (n int, err error) {
+	addrBuf := addressBuffer{
+		buf:    uintptr32(uintptr(unsafe.Pointer(to))),
+		bufLen: uint32(unsafe.Sizeof(*to)),
+	}
+	var nwritten int32
+	errno := sock_send_to(
+		int32(fd),
+		iovs,
+		int32(iovsCount),
+		unsafe.Pointer(&addrBuf),
+		int32(port),
+		int32(flags),
+		unsafe.Pointer(&nwritten),
+	)
+	if errno != 0 {
+		return int(nwritten), errno
+	}
+	return int(nwritten), nil
+}
+
+func makeIovecs(iovs []iovec, buffers [][]byte) []iovec {
+	for _, b := range buffers {
+		iovs = append(iovs, iovec{
+			ptr: uintptr32(uintptr(unsafe.Pointer(unsafe.SliceData(b)))),
+			len: uint32(len(b)),
+		})
+	}
+	return iovs
+}
+
+// Shutdown shuts down the reading side, writing side, or both sides of the
+// socket fd depending on the value of how (SHUT_RD, SHUT_WR, or SHUT_RDWR).
+func Shutdown(fd, how int) error {
+	if errno := sock_shutdown(int32(fd), int32(how)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// GetsockoptInt returns the value of the socket option opt at the given level.
+//
+// The level and option are passed through to the runtime, which allows reading
+// options that are not defined in this package.
+func GetsockoptInt(fd, level, opt int) (value int, err error) {
+	var n int32
+	errno := sock_getsockopt(int32(fd), uint32(level), uint32(opt), unsafe.Pointer(&n), 4)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
+
+// SetsockoptInt sets the value of the socket option opt at the given level.
+func SetsockoptInt(fd, level, opt int, value int) error {
+	var n = int32(value)
+	errno := sock_setsockopt(int32(fd), uint32(level), uint32(opt), unsafe.Pointer(&n), 4)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Getsockname returns the local address of the socket fd.
+func Getsockname(fd int) (sa Sockaddr, err error) {
+	var rsa RawSockaddrAny
+	buf := addressBuffer{
+		buf:    uintptr32(uintptr(unsafe.Pointer(&rsa))),
+		bufLen: uint32(unsafe.Sizeof(rsa)),
+	}
+	var port uint32
+	errno := sock_getlocaladdr(int32(fd), unsafe.Pointer(&buf), unsafe.Pointer(&port))
+	if errno != 0 {
+		return nil, errno
+	}
+	return anyToSockaddr(&rsa, port)
+}
+
+// Getpeername returns the address of the peer that the socket fd is connected
+// to.
+func Getpeername(fd int) (sa Sockaddr, err error) {
+	var rsa RawSockaddrAny
+	buf := addressBuffer{
+		buf:    uintptr32(uintptr(unsafe.Pointer(&rsa))),
+		bufLen: uint32(unsafe.Sizeof(rsa)),
+	}
+	var port uint32
+	errno := sock_getpeeraddr(int32(fd), unsafe.Pointer(&buf), unsafe.Pointer(&port))
+	if errno != 0 {
+		return nil, errno
+	}
+	return anyToSockaddr(&rsa, port)
+}
+
+func anyToSockaddr(rsa *RawSockaddrAny, port uint32) (Sockaddr, error) {
+	switch rsa.Family {
+	case AF_INET:
+		sa := &SockaddrInet4{Port: int(port)}
+		copy(sa.Addr[:], rsa.Addr[:])
+		return sa, nil
+	case AF_INET6:
+		sa := &SockaddrInet6{Port: int(port)}
+		copy(sa.Addr[:], rsa.Addr[:])
+		return sa, nil
+	case AF_UNIX:
+		return &SockaddrUnix{Name: string(rsa.Addr[:strlen(rsa.Addr[:])])}, nil
+	default:
+		return nil, syscall.EAFNOSUPPORT
+	}
+}
+
+func strlen(b []byte) (n int) {
+	for n < len(b) && b[n] != 0 {
+		n++
+	}
+	return n
+}
+
+// Addrinfo is the type of hints and results of Getaddrinfo.
+//
+// Only the Flags, Family, SocketType, and Protocol fields are used in hints.
+type Addrinfo struct {
+	Flags      int
+	Family     int
+	SocketType int
+	Protocol   int
+	Addr       Sockaddr
+
+	sockAddrInfo
+	sockAddr
+	sockData  [26]byte
+	canonname [30]byte
+}
+
+// https://github.com/WasmEdge/WasmEdge/blob/434e1fb4690/thirdparty/wasi/api.hpp#L1885
+type sockAddrInfo struct {
+	ai_flags        uint16
+	ai_family       uint8
+	ai_socktype     uint8
+	ai_protocol     uint32
+	ai_addrlen      uint32
+	ai_addr         uintptr32 // *sockAddr
+	ai_canonname    uintptr32 // null-terminated string
+	ai_canonnamelen uint32
+	ai_next         uintptr32 // *sockAddrInfo
+}
+
+type sockAddr struct {
+	sa_family   uint32
+	sa_data_len uint32
+	sa_data     uintptr32
+	_           [4]byte
+}
+
+// Getaddrinfo resolves the node and service names to socket addresses, and
+// writes them to results. It returns the number of results written, which is
+// at most len(results).
+//
+// A nil hints pointer is equivalent to a pointer to the zero value, which does
+// not restrict the address family, socket type, or protocol of the results.
+func Getaddrinfo(node, service string, hints *Addrinfo, results []Addrinfo) (int, error) {
+	if len(results) == 0 {
+		return 0, nil
+	}
+	var rawHints sockAddrInfo
+	if hints != nil {
+		rawHints = sockAddrInfo{
+			ai_flags:    uint16(hints.Flags),
+			ai_family:   uint8(hints.Family),
+			ai_socktype: uint8(hints.SocketType),
+			ai_protocol: uint32(hints.Protocol),
+		}
+	}
+	for i := range results {
+		r := &results[i]
+		r.sockAddr = sockAddr{
+			sa_data_len: uint32(unsafe.Sizeof(r.sockData)),
+			sa_data:     uintptr32(uintptr(unsafe.Pointer(&r.sockData))),
+		}
+		r.sockAddrInfo = sockAddrInfo{
+			ai_addrlen:      uint32(unsafe.Sizeof(sockAddr{})),
+			ai_addr:         uintptr32(uintptr(unsafe.Pointer(&r.sockAddr))),
+			ai_canonname:    uintptr32(uintptr(unsafe.Pointer(&r.canonname))),
+			ai_canonnamelen: uint32(unsafe.Sizeof(r.canonname)),
+		}
+		if i > 0 {
+			results[i-1].ai_next = uintptr32(uintptr(unsafe.Pointer(&r.sockAddrInfo)))
+		}
+	}
+
+	resPtr := uintptr32(uintptr(unsafe.Pointer(&results[0].sockAddrInfo)))
+	// For compatibility with WasmEdge, make sure strings are null-terminated.
+	nodePtr, nodeLen := nullTerminatedString(node)
+	servPtr, servLen := nullTerminatedString(service)
+
+	var n uint32
+	errno := sock_getaddrinfo(
+		unsafe.Pointer(nodePtr),
+		uint32(nodeLen),
+		unsafe.Pointer(servPtr),
+		uint32(servLen),
+		unsafe.Pointer(&rawHints),
+		unsafe.Pointer(&resPtr),
+		uint32(len(results)),
+		unsafe.Pointer(&n),
+	)
+	if errno != 0 {
+		return 0, errno
+	}
+
+	for i := range results[:n] {
+		r := &results[i]
+		r.Flags = int(r.ai_flags)
+		r.Family = int(r.ai_family)
+		r.SocketType = int(r.ai_socktype)
+		r.Protocol = int(r.ai_protocol)
+		port := int(binary.BigEndian.Uint16(r.sockData[:2]))
+		switch r.sa_family {
+		case AF_INET:
+			sa := &SockaddrInet4{Port: port}
+			copy(sa.Addr[:], r.sockData[2:])
+			r.Addr = sa
+		case AF_INET6:
+			sa := &SockaddrInet6{Port: port}
+			copy(sa.Addr[:], r.sockData[2:])
+			r.Addr = sa
+		default:
+			r.Addr = nil
+		}
+	}
+	return int(n), nil
+}
+
+func nullTerminatedString(s string) (*byte, int) {
+	if n := strings.IndexByte(s, 0); n >= 0 {
+		s = s[:n+1]
+		return unsafe.StringData(s), len(s)
+	} else {
+		b := append([]byte(s), 0)
+		return unsafe.SliceData(b), len(b)
+	}
+}
@@ -0,0 +1,107 @@
+//go:build wasip1
+
+package syscall_test
+
+import (
+	"syscall"
+	"testing"
+
+	wasisyscall "github.com/stealthrocket/net/wasip1/syscall"
+)
+
+func TestSendRecvBuffers(t *testing.T) {
+	server := socket(t, wasisyscall.AF_INET, wasisyscall.SOCK_DGRAM)
+	if err := wasisyscall.Bind(server, &wasisyscall.SockaddrInet4{Addr: [4]byte{127, 0, 0, 1}}); err != nil {
+		t.Fatal("bind:", err)
+	}
+	sa, err := wasisyscall.Getsockname(server)
+	if err != nil {
+		t.Fatal("getsockname:", err)
+	}
+	addr, ok := sa.(*wasisyscall.SockaddrInet4)
+	if !ok {
+		t.Fatalf("wrong socket address type: %T", sa)
+	}
+	if addr.Port == 0 {
+		t.Fatal("socket bound to port zero")
+	}
+
+	client := socket(t, wasisyscall.AF_INET, wasisyscall.SOCK_DGRAM)
+	n, err := wasisyscall.SendmsgBuffers(client, [][]byte{[]byte("hello, "), []byte("world!")}, addr, 0)
+	if err != nil {
+		t.Fatal("send:", err)
+	}
+	if n != 13 {
+		t.Fatalf("wrong number of bytes sent: %d", n)
+	}
+
+	a := make([]byte, 7)
+	b := make([]byte, 16)
+	n, _, from, err := wasisyscall.RecvmsgBuffers(server, [][]byte{a, b}, wasisyscall.MSG_PEEK)
+	if err != nil {
+		t.Fatal("recv (peek):", err)
+	}
+	if n != 13 || string(a) != "hello, " || string(b[:n-len(a)]) != "world!" {
+		t.Fatalf("wrong message received: %q %q", a, b[:n-len(a)])
+	}
+	if _, ok := from.(*wasisyscall.SockaddrInet4); !ok {
+		t.Fatalf("wrong sender address type: %T", from)
+	}
+
+	p := make([]byte, 5)
+	n, flags, _, err := wasisyscall.RecvmsgBuffers(server, [][]byte{p}, 0)
+	if err != nil {
+		t.Fatal("recv:", err)
+	}
+	if n != 5 || string(p) != "hello" {
+		t.Fatalf("wrong message received: %q", p[:n])
+	}
+	if flags&wasisyscall.MSG_TRUNC == 0 {
+		t.Error("message truncation was not reported")
+	}
+}
+
+func TestSocketOption(t *testing.T) {
+	fd := socket(t, wasisyscall.AF_INET, wasisyscall.SOCK_STREAM)
+
+	if err := wasisyscall.SetsockoptInt(fd, wasisyscall.SOL_SOCKET, wasisyscall.SO_REUSEADDR, 1); err != nil {
+		t.Fatal("setsockopt:", err)
+	}
+	v, err := wasisyscall.GetsockoptInt(fd, wasisyscall.SOL_SOCKET, wasisyscall.SO_REUSEADDR)
+	if err != nil {
+		t.Fatal("getsockopt:", err)
+	}
+	if v == 0 {
+		t.Error("SO_REUSEADDR was not set")
+	}
+}
+
+func TestGetaddrinfoNilHints(t *testing.T) {
+	results := make([]wasisyscall.Addrinfo, 4)
+	n, err := wasisyscall.Getaddrinfo("127.0.0.1", "80", nil, results)
+	if err != nil {
+		if err == syscall.ENOSYS {
+			t.Skip("getaddrinfo is not supported by the runtime")
+		}
+		t.Fatal("getaddrinfo:", err)
+	}
+	if n == 0 {
+		t.Fatal("no results")
+	}
+	addr, ok := results[0].Addr.(*wasisyscall.SockaddrInet4)
+	if !ok {
+		t.Fatalf("wrong socket address type: %T", results[0].Addr)
+	}
+	if addr.Addr != [4]byte{127, 0, 0, 1} || addr.Port != 80 {
+		t.Fatalf("wrong socket address: %v:%d", addr.Addr, addr.Port)
+	}
+}
+
+func socket(t *testing.T, family, sotype int) int {
+	fd, err := wasisyscall.Socket(family, sotype, 0)
+	if err != nil {
+		t.Fatal("socket:", err)
+	}
+	t.Cleanup(func() { syscall.Close(fd) })
+	return fd
+}
@@ -0,0 +1,51 @@
+//go:build wasip1
+
+package wasip1
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	wasisyscall "github.com/stealthrocket/net/wasip1/syscall"
+)
+
+func TestSockaddrInet6Zone(t *testing.T) {
+	// IPv6 zones cannot be represented in the socket addresses of the WasmEdge
+	// specification, they are dropped so link-local addresses remain usable
+	// when the host can select the interface.
+	addr := &net.TCPAddr{IP: net.ParseIP("fe80::1"), Port: 443, Zone: "eth0"}
+	sa, err := socketAddress(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sa.(*wasisyscall.SockaddrInet6); !ok {
+		t.Fatalf("wrong socket address type: %T", sa)
+	}
+
+	want := &net.TCPAddr{IP: addr.IP, Port: addr.Port}
+	got := new(net.TCPAddr)
+	setNetAddr(SOCK_STREAM, got, sa)
+	if got.String() != want.String() {
+		t.Fatalf("wrong network address: want=%s got=%s", want, got)
+	}
+
+	addrPort := netip.MustParseAddrPort("[fe80::1%eth0]:443")
+	sa, err = addrPortSockaddr("tcp6", addrPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inet6, ok := sa.(*wasisyscall.SockaddrInet6)
+	if !ok {
+		t.Fatalf("wrong socket address type: %T", sa)
+	}
+	if inet6.Addr != addrPort.Addr().As16() || inet6.Port != 443 {
+		t.Fatalf("wrong socket address: %+v", inet6)
+	}
+
+	op := new(packetOp)
+	op.setAddrPort(addrPort)
+	if got, want := op.addrPort(), netip.MustParseAddrPort("[fe80::1]:443"); got != want {
+		t.Fatalf("wrong packet address: want=%s got=%s", want, got)
+	}
+}
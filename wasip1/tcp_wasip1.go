@@ -0,0 +1,208 @@
+//go:build wasip1
+
+package wasip1
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"syscall"
+	"time"
+)
+
+// TCPConn is the type of connections returned by DialTCP and accepted by a
+// TCPListener.
+//
+// The type embeds the *net.TCPConn created from the socket, so it has the same
+// method set as its net counterpart, but its local and remote addresses are
+// initialized, which the net package cannot do on GOOS=wasip1.
+//
+// Writing net.Buffers with their WriteTo method does not use vectored writes
+// on GOOS=wasip1, neither on a TCPConn nor on the *net.TCPConn returned by
+// Dial; callers must use the WriteBuffers method or function instead.
+type TCPConn struct {
+	*net.TCPConn
+}
+
+// LocalAddrPort returns the local address as a netip.AddrPort.
+func (c *TCPConn) LocalAddrPort() netip.AddrPort {
+	return c.LocalAddr().(*net.TCPAddr).AddrPort()
+}
+
+// RemoteAddrPort returns the remote address as a netip.AddrPort.
+func (c *TCPConn) RemoteAddrPort() netip.AddrPort {
+	return c.RemoteAddr().(*net.TCPAddr).AddrPort()
+}
+
+// WriteBuffers writes the contents of v to the connection with vectored writes,
+// consuming the buffers like net.Buffers.WriteTo.
+func (c *TCPConn) WriteBuffers(v *net.Buffers) (int64, error) {
+	rawConn, err := c.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	n, err := writeBuffers(rawConn, v)
+	if err != nil {
+		err = c.opError("writev", err)
+	}
+	return n, err
+}
+
+// ReadBuffers reads data from the connection into bufs with a single scatter
+// read, filling the buffers in order. It returns the total number of bytes
+// read.
+func (c *TCPConn) ReadBuffers(bufs [][]byte) (int, error) {
+	rawConn, err := c.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	n, err := readBuffers(rawConn, bufs)
+	if err != nil && err != io.EOF {
+		err = c.opError("readv", err)
+	}
+	return n, err
+}
+
+func (c *TCPConn) opError(op string, err error) error {
+	return &net.OpError{
+		Op:     op,
+		Net:    "tcp",
+		Source: c.LocalAddr(),
+		Addr:   c.RemoteAddr(),
+		Err:    err,
+	}
+}
+
+// TCPListener is the type of listeners returned by ListenTCP.
+type TCPListener struct {
+	listener
+}
+
+// Accept implements the Accept method in the net.Listener interface.
+func (l *TCPListener) Accept() (net.Conn, error) {
+	return l.AcceptTCP()
+}
+
+// AcceptTCP accepts the next incoming call and returns the new connection.
+func (l *TCPListener) AcceptTCP() (*TCPConn, error) {
+	c, err := l.listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return newTCPConn(c)
+}
+
+// SetDeadline sets the deadline associated with the listener. A zero time
+// value disables the deadline.
+func (l *TCPListener) SetDeadline(t time.Time) error {
+	return l.Listener.(*net.TCPListener).SetDeadline(t)
+}
+
+// File returns a copy of the underlying file.
+//
+// WASI preview 1 has no function to duplicate file descriptors, so File always
+// returns an error on runtimes which do not support it.
+func (l *TCPListener) File() (*os.File, error) {
+	return l.Listener.(*net.TCPListener).File()
+}
+
+func (l *TCPListener) SyscallConn() (syscall.RawConn, error) {
+	return l.Listener.(*net.TCPListener).SyscallConn()
+}
+
+func newTCPConn(c net.Conn) (*TCPConn, error) {
+	tc, ok := c.(*net.TCPConn)
+	if !ok {
+		c.Close()
+		return nil, &net.OpError{
+			Op:     "dial",
+			Net:    c.LocalAddr().Network(),
+			Source: c.LocalAddr(),
+			Addr:   c.RemoteAddr(),
+			Err:    syscall.EPROTOTYPE,
+		}
+	}
+	return &TCPConn{tc}, nil
+}
+
+// DialTCP acts like Dial for TCP networks.
+//
+// The network must be "tcp", "tcp4", or "tcp6". If laddr is nil, a local
+// address is automatically chosen.
+func DialTCP(network string, laddr, raddr *net.TCPAddr) (*TCPConn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, unknownNetworkError("dial", network, tcpOpAddr(raddr))
+	}
+	if raddr == nil {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: errMissingAddress}
+	}
+	var local net.Addr
+	if laddr != nil {
+		local = laddr
+	}
+	c, err := dialAddr(context.Background(), local, raddr)
+	if err != nil {
+		return nil, dialErr(raddr, err)
+	}
+	return newTCPConn(c)
+}
+
+// ListenTCP acts like Listen for TCP networks.
+//
+// The network must be "tcp", "tcp4", or "tcp6". If the IP field of laddr is nil
+// or an unspecified IP address, ListenTCP listens on all available addresses
+// of the local system.
+//
+// Unlike Listen, the listener is always backed by a single socket. When the
+// network is "tcp" and the IP of laddr is unspecified, ListenTCP binds the IPv6
+// wildcard address, which only accepts IPv4 connections on hosts where IPv6
+// sockets are dual-stack by default, see ListenConfig.ListenPacket. Programs
+// which must accept IPv4 connections on other hosts should use Listen.
+func ListenTCP(network string, laddr *net.TCPAddr) (*TCPListener, error) {
+	addr := &net.TCPAddr{}
+	if laddr != nil {
+		*addr = *laddr
+	}
+	switch network {
+	case "tcp", "tcp4":
+		if addr.IP == nil {
+			addr.IP = net.IPv4zero
+		}
+	case "tcp6":
+		if addr.IP == nil {
+			addr.IP = net.IPv6zero
+		}
+	default:
+		return nil, unknownNetworkError("listen", network, addr)
+	}
+
+	var lc ListenConfig
+	var l net.Listener
+	var err error
+	if dualStack, ok := dualStackAddr(network, addr); ok {
+		// The listener must be backed by a single socket, which accepts IPv4
+		// connections if the host's IPv6 sockets are dual-stack.
+		if l, err = lc.listenAddr(dualStack); err != nil && !isAddrNotAvailable(err) {
+			return nil, listenErr(addr, err)
+		}
+	}
+	if l == nil {
+		l, err = lc.listenAddr(addr)
+	}
+	if err != nil {
+		return nil, listenErr(addr, err)
+	}
+	return &TCPListener{listener: *l.(*listener)}, nil
+}
+
+// tcpOpAddr converts a to a net.Addr which is nil if a is nil.
+func tcpOpAddr(a *net.TCPAddr) net.Addr {
+	if a == nil {
+		return nil
+	}
+	return a
+}
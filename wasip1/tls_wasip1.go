@@ -0,0 +1,139 @@
+//go:build wasip1
+
+package wasip1
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// TLSDialer is the equivalent of tls.Dialer for GOOS=wasip1, it dials TLS
+// connections using the dial functions of this package.
+//
+// The zero-value is a valid dialer which uses the default TLS configuration.
+type TLSDialer struct {
+	// NetDialer is the dialer used to establish the underlying network
+	// connections. When nil, a zero Dialer is used. The Timeout and Deadline
+	// of the dialer also bound the TLS handshake.
+	NetDialer *Dialer
+
+	// Config is the TLS configuration of the connections, nil is equivalent
+	// to the zero configuration. When the ServerName is empty, it is derived
+	// from the address passed to Dial. When RootCAs is nil, the certificates
+	// returned by SystemCertPool are used, and dialing fails if they cannot be
+	// loaded.
+	Config *tls.Config
+}
+
+// Dial connects to the address on the named network and initiates a TLS
+// handshake, returning the resulting TLS connection.
+func (d *TLSDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext connects to the address on the named network and performs a TLS
+// handshake. The context bounds both the establishment of the connection and
+// the handshake.
+//
+// The returned connection, if any, is always of type *tls.Conn.
+func (d *TLSDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	c, err := d.dial(ctx, network, address)
+	if err != nil {
+		// Avoid returning a non-nil interface holding a nil pointer.
+		return nil, err
+	}
+	return c, nil
+}
+
+func (d *TLSDialer) dial(ctx context.Context, network, address string) (*tls.Conn, error) {
+	netDialer := d.NetDialer
+	if netDialer == nil {
+		netDialer = new(Dialer)
+	}
+	if netDialer.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, netDialer.Timeout)
+		defer cancel()
+	}
+	if !netDialer.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, netDialer.Deadline)
+		defer cancel()
+	}
+
+	// Set the server name from the address, which is used for SNI and to
+	// verify the server certificate.
+	hostname, _, err := net.SplitHostPort(address)
+	if err != nil {
+		hostname = address
+	}
+	config, err := clientConfig(d.Config, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	rawConn, err := netDialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := tls.Client(rawConn, config)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// clientConfig returns the configuration of TLS client connections to the
+// server, filling the server name and root certificates if config has none.
+func clientConfig(config *tls.Config, serverName string) (*tls.Config, error) {
+	if config == nil {
+		config = new(tls.Config)
+	}
+	if config.ServerName == "" || config.RootCAs == nil && !config.InsecureSkipVerify {
+		config = config.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = serverName
+	}
+	if config.RootCAs == nil && !config.InsecureSkipVerify {
+		roots, err := systemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("loading root certificates: %w", err)
+		}
+		config.RootCAs = roots
+	}
+	return config, nil
+}
+
+// DialTLS connects to the address on the named network using TLS, see
+// TLSDialer for details.
+func DialTLS(network, address string, config *tls.Config) (*tls.Conn, error) {
+	return DialTLSContext(context.Background(), network, address, config)
+}
+
+// DialTLSContext is like DialTLS but it accepts a context bounding the
+// establishment of the connection and the TLS handshake.
+func DialTLSContext(ctx context.Context, network, address string, config *tls.Config) (*tls.Conn, error) {
+	d := &TLSDialer{Config: config}
+	return d.dial(ctx, network, address)
+}
+
+// ListenTLS creates a TLS listener accepting connections on the local network
+// address. The configuration must have at least one certificate, or set
+// GetCertificate or GetConfigForClient.
+func ListenTLS(network, address string, config *tls.Config) (net.Listener, error) {
+	if config == nil || len(config.Certificates) == 0 &&
+		config.GetCertificate == nil && config.GetConfigForClient == nil {
+		return nil, errors.New("tls: neither Certificates, GetCertificate, nor GetConfigForClient set in Config")
+	}
+	l, err := Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return tls.NewListener(l, config), nil
+}
@@ -0,0 +1,103 @@
+//go:build wasip1
+
+package wasip1_test
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/net/internal/testcert"
+	"github.com/stealthrocket/net/wasip1"
+)
+
+func TestTLS(t *testing.T) {
+	cert, roots, err := testcert.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := wasip1.ListenTLS("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	serverNames := make(chan string, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		tc := c.(*tls.Conn)
+		if err := tc.Handshake(); err != nil {
+			return
+		}
+		serverNames <- tc.ConnectionState().ServerName
+		io.Copy(c, c)
+	}()
+
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	d := &wasip1.TLSDialer{Config: &tls.Config{RootCAs: roots}}
+	c, err := d.DialContext(context.Background(), "tcp", net.JoinHostPort("localhost", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if serverName := <-serverNames; serverName != "localhost" {
+		t.Errorf("wrong server name: want=localhost got=%q", serverName)
+	}
+	if _, err := io.WriteString(c, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 5)
+	if _, err := io.ReadFull(c, b); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello" {
+		t.Fatalf("wrong data received: %q", b)
+	}
+
+	// The certificate is not valid for this name, the handshake must fail.
+	_, err = wasip1.DialTLS("tcp", l.Addr().String(), &tls.Config{
+		RootCAs:    roots,
+		ServerName: "example.com",
+	})
+	if err == nil {
+		t.Fatal("the server certificate was not verified")
+	}
+}
+
+func TestTLSHandshakeTimeout(t *testing.T) {
+	// The server accepts connections but never completes the handshake.
+	l, err := wasip1.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = wasip1.DialTLSContext(ctx, "tcp", l.Addr().String(), nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wrong error returned by the handshake: %v", err)
+	}
+}
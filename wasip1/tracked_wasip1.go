@@ -0,0 +1,273 @@
+//go:build wasip1
+
+package wasip1
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// TrackedListener is a net.Listener which keeps track of the connections that
+// it accepted, allowing servers to gracefully shut down.
+//
+// The listener also supports limiting the number of concurrent connections;
+// when the limit is reached, the listener stops accepting connections from
+// the underlying socket until an active connection is closed, leaving new
+// connections in the backlog of the socket.
+//
+// The connections returned by the listener wrap those of the underlying
+// listener, programs which need access to the original connection types can
+// use their NetConn method.
+type TrackedListener struct {
+	listener net.Listener
+	slots    chan struct{} // nil when the number of connections is unlimited
+	conns    chan net.Conn
+	done     chan struct{}
+	drained  chan struct{}
+	once     sync.Once
+	close    sync.Once
+	deadline deadline
+
+	mutex  sync.Mutex
+	active map[*trackedConn]struct{}
+	closed bool
+	err    error
+}
+
+// NewTrackedListener returns a TrackedListener accepting connections from l.
+//
+// The maxConns argument limits the number of connections that may be active
+// at the same time, zero or a negative value means no limit.
+func NewTrackedListener(l net.Listener, maxConns int) *TrackedListener {
+	t := &TrackedListener{
+		listener: l,
+		conns:    make(chan net.Conn),
+		done:     make(chan struct{}),
+		drained:  make(chan struct{}),
+		active:   make(map[*trackedConn]struct{}),
+	}
+	if maxConns > 0 {
+		t.slots = make(chan struct{}, maxConns)
+	}
+	go t.serve()
+	return t
+}
+
+func (l *TrackedListener) serve() {
+	for {
+		if l.slots != nil {
+			select {
+			case l.slots <- struct{}{}:
+			case <-l.done:
+				return
+			}
+		}
+		c, err := l.accept()
+		if err != nil {
+			l.release()
+			l.shutdown(err)
+			return
+		}
+		select {
+		case l.conns <- c:
+		case <-l.done:
+			c.Close()
+			l.release()
+			return
+		}
+	}
+}
+
+// accept accepts the next connection from the underlying listener. Like the
+// net/http server, it retries after temporary errors with an exponential
+// backoff, starting at 5ms and capped at 1s.
+func (l *TrackedListener) accept() (net.Conn, error) {
+	var delay time.Duration
+	for {
+		c, err := l.listener.Accept()
+		if err == nil || !isTemporary(err) {
+			return c, err
+		}
+		if delay == 0 {
+			delay = 5 * time.Millisecond
+		} else {
+			delay *= 2
+		}
+		if delay > time.Second {
+			delay = time.Second
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-l.done:
+			timer.Stop()
+			return nil, net.ErrClosed
+		}
+	}
+}
+
+// release frees a connection slot, if the number of connections is limited.
+func (l *TrackedListener) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+func (l *TrackedListener) shutdown(err error) {
+	l.once.Do(func() {
+		l.mutex.Lock()
+		l.closed, l.err = true, err
+		close(l.done)
+		l.drain()
+		l.mutex.Unlock()
+	})
+}
+
+// drain signals that the listener was closed and all the connections are
+// closed; it must be called with the mutex held.
+func (l *TrackedListener) drain() {
+	if l.closed && len(l.active) == 0 && l.active != nil {
+		l.active = nil
+		close(l.drained)
+	}
+}
+
+// Accept waits for and returns the next connection to the listener.
+func (l *TrackedListener) Accept() (net.Conn, error) {
+	return l.AcceptContext(context.Background())
+}
+
+// AcceptContext is like Accept but it returns early with the context error if
+// the context is done before a connection was accepted.
+func (l *TrackedListener) AcceptContext(ctx context.Context) (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return l.track(c)
+	case <-l.done:
+		l.mutex.Lock()
+		err := l.err
+		l.mutex.Unlock()
+		if _, ok := err.(*net.OpError); ok {
+			return nil, err // returned by the underlying listener
+		}
+		return nil, l.opError(err)
+	case <-l.deadline.wait():
+		return nil, l.opError(os.ErrDeadlineExceeded)
+	case <-ctx.Done():
+		return nil, l.opError(ctx.Err())
+	}
+}
+
+func (l *TrackedListener) track(c net.Conn) (net.Conn, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.closed {
+		c.Close()
+		l.release()
+		return nil, l.opError(net.ErrClosed)
+	}
+	tc := &trackedConn{Conn: c, listener: l}
+	l.active[tc] = struct{}{}
+	return tc, nil
+}
+
+func (l *TrackedListener) remove(c *trackedConn) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	delete(l.active, c)
+	l.release()
+	l.drain()
+}
+
+func (l *TrackedListener) opError(err error) error {
+	return &net.OpError{
+		Op:   "accept",
+		Net:  l.Addr().Network(),
+		Addr: l.Addr(),
+		Err:  err,
+	}
+}
+
+// Addr returns the address of the underlying listener.
+func (l *TrackedListener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// SetDeadline sets the deadline for future and pending calls to Accept. A zero
+// time value disables the deadline.
+func (l *TrackedListener) SetDeadline(t time.Time) error {
+	l.deadline.set(t)
+	return nil
+}
+
+// ActiveConns returns the number of connections that were accepted and are not
+// closed yet.
+func (l *TrackedListener) ActiveConns() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return len(l.active)
+}
+
+// Close stops accepting connections and closes the underlying listener. The
+// active connections are not closed.
+//
+// Only the first call closes the underlying listener and returns its error,
+// subsequent calls return nil.
+func (l *TrackedListener) Close() (err error) {
+	l.shutdown(net.ErrClosed)
+	l.close.Do(func() { err = l.listener.Close() })
+	return err
+}
+
+// Shutdown closes the listener and waits for all the active connections to be
+// closed. If the context is done first, the remaining connections are closed
+// and Shutdown returns the context error.
+//
+// Calling Shutdown after Close only waits for the active connections.
+func (l *TrackedListener) Shutdown(ctx context.Context) error {
+	err := l.Close()
+	select {
+	case <-l.drained:
+		return err
+	case <-ctx.Done():
+		l.closeConns()
+		return ctx.Err()
+	}
+}
+
+func (l *TrackedListener) closeConns() {
+	l.mutex.Lock()
+	conns := make([]*trackedConn, 0, len(l.active))
+	for c := range l.active {
+		conns = append(conns, c)
+	}
+	l.mutex.Unlock()
+
+	for _, c := range conns {
+		c.Close()
+	}
+}
+
+type trackedConn struct {
+	net.Conn
+	listener *TrackedListener
+	once     sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() { c.listener.remove(c) })
+	return err
+}
+
+// NetConn returns the connection accepted by the underlying listener.
+func (c *trackedConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+var (
+	_ net.Listener = (*TrackedListener)(nil)
+)
@@ -0,0 +1,142 @@
+//go:build wasip1
+
+package wasip1
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"syscall"
+)
+
+var errMissingAddress = errors.New("missing address")
+
+// UDPConn is the equivalent of net.UDPConn for GOOS=wasip1.
+//
+// Values of this type are returned by the dial and listen functions of this
+// package when the network is "udp", "udp4", or "udp6".
+type UDPConn struct {
+	*packetConn
+}
+
+// ReadFromUDP acts like ReadFrom but returns a *net.UDPAddr.
+func (c *UDPConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	n, _, _, addr, err := c.ReadMsgUDP(b, nil)
+	return n, addr, err
+}
+
+// ReadFromUDPAddrPort acts like ReadFrom but returns a netip.AddrPort.
+func (c *UDPConn) ReadFromUDPAddrPort(b []byte) (int, netip.AddrPort, error) {
+	n, _, _, addr, err := c.ReadMsgUDPAddrPort(b, nil)
+	return n, addr, err
+}
+
+// WriteToUDP acts like WriteTo but takes a *net.UDPAddr.
+func (c *UDPConn) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
+	n, _, err := c.WriteMsgUDP(b, nil, addr)
+	return n, err
+}
+
+// WriteToUDPAddrPort acts like WriteTo but takes a netip.AddrPort.
+func (c *UDPConn) WriteToUDPAddrPort(b []byte, addr netip.AddrPort) (int, error) {
+	n, _, err := c.WriteMsgUDPAddrPort(b, nil, addr)
+	return n, err
+}
+
+// File returns a copy of the underlying file.
+//
+// WASI preview 1 has no function to duplicate file descriptors, so File always
+// returns an error.
+func (c *UDPConn) File() (*os.File, error) {
+	return nil, &net.OpError{
+		Op:   "file",
+		Net:  c.laddr.Network(),
+		Addr: c.laddr,
+		Err:  syscall.ENOSYS,
+	}
+}
+
+// DialUDP acts like Dial for UDP networks.
+//
+// The network must be "udp", "udp4", or "udp6". If laddr is nil, a local
+// address is automatically chosen.
+func DialUDP(network string, laddr, raddr *net.UDPAddr) (*UDPConn, error) {
+	switch network {
+	case "udp", "udp4", "udp6":
+	default:
+		return nil, unknownNetworkError("dial", network, udpOpAddr(raddr))
+	}
+	if raddr == nil {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: errMissingAddress}
+	}
+	var local net.Addr
+	if laddr != nil {
+		local = laddr
+	}
+	c, err := dialAddr(context.Background(), local, raddr)
+	if err != nil {
+		return nil, dialErr(raddr, err)
+	}
+	return c.(*UDPConn), nil
+}
+
+// ListenUDP acts like ListenPacket for UDP networks.
+//
+// The network must be "udp", "udp4", or "udp6". If the IP field of laddr is nil
+// or an unspecified IP address, ListenUDP listens on all available addresses
+// of the local system.
+//
+// The connection is backed by a single socket. When the network is "udp" and
+// the IP of laddr is unspecified, ListenUDP binds the IPv6 wildcard address,
+// which only receives IPv4 datagrams on hosts where IPv6 sockets are
+// dual-stack by default, see ListenConfig.ListenPacket.
+func ListenUDP(network string, laddr *net.UDPAddr) (*UDPConn, error) {
+	addr := &net.UDPAddr{}
+	if laddr != nil {
+		*addr = *laddr
+	}
+	switch network {
+	case "udp", "udp4":
+		if addr.IP == nil {
+			addr.IP = net.IPv4zero
+		}
+	case "udp6":
+		if addr.IP == nil {
+			addr.IP = net.IPv6zero
+		}
+	default:
+		return nil, unknownNetworkError("listen", network, addr)
+	}
+
+	var c net.PacketConn
+	var err error
+	if dualStack, ok := dualStackAddr(network, addr); ok {
+		// The connection must be backed by a single socket, which receives
+		// IPv4 datagrams if the host's IPv6 sockets are dual-stack.
+		if c, err = listenPacketAddr(dualStack); err != nil && !isAddrNotAvailable(err) {
+			return nil, listenErr(addr, err)
+		}
+	}
+	if c == nil {
+		c, err = listenPacketAddr(addr)
+	}
+	if err != nil {
+		return nil, listenErr(addr, err)
+	}
+	return c.(*UDPConn), nil
+}
+
+// udpOpAddr converts a to a net.Addr which is nil if a is nil.
+func udpOpAddr(a *net.UDPAddr) net.Addr {
+	if a == nil {
+		return nil
+	}
+	return a
+}
+
+var (
+	_ net.Conn       = (*UDPConn)(nil)
+	_ net.PacketConn = (*UDPConn)(nil)
+)
@@ -2,59 +2,384 @@
 
 package wasip1
 
-// Unix sockets are not yet supported for GOOS=wasip1 because there is no
-// mechanism to create a *net.UnixConn or *net.UnixListener from an *os.File
-// due to WASI preview 1 not having the concept of unix sockets and only
-// having file types for datagram and stream sockets, which are mapped to
-// UDP and TCP sockets by the net package.
+// There is no mechanism to create a *net.UnixConn or *net.UnixListener from an
+// *os.File for GOOS=wasip1 because WASI preview 1 does not have the concept of
+// unix sockets and only has file types for datagram and stream sockets, which
+// are mapped to UDP and TCP sockets by the net package.
 //
-// We emulate unix sockets to provide minimum support when calling Dial with
-// a "unix" network. The downside is that connections returned by the wasip1
-// dial functions are not actually of type *net.UnixConn, applications that
-// used type assertions to dynamically discover the connection type will not
-// be compatible with this approach. The same limitation applies to listeners.
+// We emulate unix sockets with the UnixConn and UnixListener types, which have
+// the same method sets as their counterparts in the net package. Applications
+// that used type assertions to dynamically discover the connection type must
+// be adapted to use the types of this package, but the methods they call on
+// the connections and listeners remain the same.
 
 import (
+	"context"
+	"io"
 	"net"
+	"os"
+	"sync"
 	"syscall"
+	"time"
 )
 
-type unixConn struct {
-	net.Conn
-	laddr net.UnixAddr
-	raddr net.UnixAddr
+// UnixConn is the equivalent of net.UnixConn for GOOS=wasip1.
+//
+// Values of this type are returned by the dial and listen functions of this
+// package when the network is "unix" or "unixgram", including DialUnix and
+// ListenUnixgram.
+type UnixConn struct {
+	conn   net.Conn
+	packet *packetConn // non-nil for datagram sockets
+	laddr  net.UnixAddr
+	raddr  net.UnixAddr
+}
+
+func newUnixPacketConn(c *packetConn) *UnixConn {
+	conn := &UnixConn{conn: c, packet: c}
+	if a, ok := c.laddr.(*net.UnixAddr); ok {
+		conn.laddr = *a
+	}
+	if a, ok := c.raddr.(*net.UnixAddr); ok {
+		conn.raddr = *a
+	}
+	return conn
+}
+
+func (c *UnixConn) opError(op string, err error) error {
+	return &net.OpError{
+		Op:     op,
+		Net:    c.laddr.Network(),
+		Source: c.LocalAddr(),
+		Addr:   c.RemoteAddr(),
+		Err:    err,
+	}
+}
+
+func (c *UnixConn) Read(b []byte) (int, error) {
+	return c.conn.Read(b)
+}
+
+func (c *UnixConn) Write(b []byte) (int, error) {
+	return c.conn.Write(b)
+}
+
+func (c *UnixConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *UnixConn) CloseRead() error {
+	if cr, ok := c.conn.(closeReader); ok {
+		return cr.CloseRead()
+	}
+	return c.opError("close", syscall.ENOTSUP)
 }
 
-func (c *unixConn) LocalAddr() net.Addr {
+func (c *UnixConn) CloseWrite() error {
+	if cw, ok := c.conn.(closeWriter); ok {
+		return cw.CloseWrite()
+	}
+	return c.opError("close", syscall.ENOTSUP)
+}
+
+// WriteBuffers writes the contents of v to the connection with vectored writes,
+// consuming the buffers like net.Buffers.WriteTo.
+func (c *UnixConn) WriteBuffers(v *net.Buffers) (int64, error) {
+	rawConn, err := c.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	n, err := writeBuffers(rawConn, v)
+	if err != nil {
+		err = c.opError("writev", err)
+	}
+	return n, err
+}
+
+// ReadBuffers reads data from the connection into bufs with a single scatter
+// read, filling the buffers in order. It returns the total number of bytes
+// read.
+func (c *UnixConn) ReadBuffers(bufs [][]byte) (int, error) {
+	rawConn, err := c.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	n, err := readBuffers(rawConn, bufs)
+	if err != nil && err != io.EOF {
+		err = c.opError("readv", err)
+	}
+	return n, err
+}
+
+// ReadFrom implements the net.PacketConn ReadFrom method.
+func (c *UnixConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := c.ReadFromUnix(b)
+	if addr == nil {
+		return n, nil, err
+	}
+	return n, addr, err
+}
+
+// ReadFromUnix acts like ReadFrom but returns a *net.UnixAddr.
+func (c *UnixConn) ReadFromUnix(b []byte) (int, *net.UnixAddr, error) {
+	n, _, _, addr, err := c.ReadMsgUnix(b, nil)
+	return n, addr, err
+}
+
+// ReadMsgUnix reads a message from c, copying the payload into b and the
+// associated out-of-band data into oob.
+//
+// Out-of-band data is not supported by the WASI socket extensions, oobn is
+// always zero.
+func (c *UnixConn) ReadMsgUnix(b, oob []byte) (n, oobn, flags int, addr *net.UnixAddr, err error) {
+	if c.packet != nil {
+		return c.packet.ReadMsgUnix(b, oob)
+	}
+	n, err = c.conn.Read(b)
+	return
+}
+
+// WriteTo implements the net.PacketConn WriteTo method.
+func (c *UnixConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	a, ok := addr.(*net.UnixAddr)
+	if !ok {
+		return 0, c.opError("write", syscall.EINVAL)
+	}
+	return c.WriteToUnix(b, a)
+}
+
+// WriteToUnix acts like WriteTo but takes a *net.UnixAddr.
+func (c *UnixConn) WriteToUnix(b []byte, addr *net.UnixAddr) (int, error) {
+	n, _, err := c.WriteMsgUnix(b, nil, addr)
+	return n, err
+}
+
+// WriteMsgUnix writes a message to addr, or to the remote address of c when
+// addr is nil.
+//
+// Out-of-band data is not supported by the WASI socket extensions, the method
+// returns an error if oob is not empty.
+func (c *UnixConn) WriteMsgUnix(b, oob []byte, addr *net.UnixAddr) (n, oobn int, err error) {
+	if len(oob) != 0 {
+		return 0, 0, c.opError("write", syscall.ENOTSUP)
+	}
+	if addr == nil {
+		n, err = c.conn.Write(b)
+		return n, 0, err
+	}
+	if c.packet == nil {
+		return 0, 0, c.opError("write", net.ErrWriteToConnected)
+	}
+	return c.packet.WriteMsgUnix(b, oob, addr)
+}
+
+func (c *UnixConn) LocalAddr() net.Addr {
 	return &c.laddr
 }
 
-func (c *unixConn) RemoteAddr() net.Addr {
+func (c *UnixConn) RemoteAddr() net.Addr {
 	return &c.raddr
 }
 
-func (c *unixConn) CloseRead() error {
-	if cr, ok := c.Conn.(closeReader); ok {
-		return cr.CloseRead()
+func (c *UnixConn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+func (c *UnixConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *UnixConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+func (c *UnixConn) SetReadBuffer(bytes int) error {
+	if b, ok := c.conn.(interface{ SetReadBuffer(int) error }); ok {
+		return b.SetReadBuffer(bytes)
 	}
-	return &net.OpError{
-		Op:     "close",
-		Net:    "unix",
-		Source: c.LocalAddr(),
-		Err:    syscall.ENOTSUP,
+	return c.opError("set", syscall.ENOTSUP)
+}
+
+func (c *UnixConn) SetWriteBuffer(bytes int) error {
+	if b, ok := c.conn.(interface{ SetWriteBuffer(int) error }); ok {
+		return b.SetWriteBuffer(bytes)
 	}
+	return c.opError("set", syscall.ENOTSUP)
 }
 
-func (c *unixConn) CloseWrite() error {
-	if cw, ok := c.Conn.(closeWriter); ok {
-		return cw.CloseWrite()
+// File returns a copy of the underlying file.
+//
+// WASI preview 1 has no function to duplicate file descriptors, so File always
+// returns an error on runtimes which do not support it.
+func (c *UnixConn) File() (*os.File, error) {
+	if f, ok := c.conn.(interface{ File() (*os.File, error) }); ok {
+		return f.File()
 	}
-	return &net.OpError{
-		Op:     "close",
-		Net:    "unix",
-		Source: c.LocalAddr(),
-		Err:    syscall.ENOTSUP,
+	return nil, c.opError("file", syscall.ENOSYS)
+}
+
+func (c *UnixConn) SyscallConn() (syscall.RawConn, error) {
+	if sc, ok := c.conn.(syscall.Conn); ok {
+		return sc.SyscallConn()
+	}
+	return nil, c.opError("raw-conn", syscall.ENOTSUP)
+}
+
+// UnixListener is the equivalent of net.UnixListener for GOOS=wasip1.
+//
+// Values of this type are returned by Listen and ListenUnix when the network
+// is "unix".
+type UnixListener struct {
+	listener
+	addr   net.UnixAddr
+	path   string
+	unlink bool
+	once   sync.Once
+}
+
+// Accept implements the Accept method in the net.Listener interface.
+func (l *UnixListener) Accept() (net.Conn, error) {
+	return l.AcceptUnix()
+}
+
+// AcceptUnix accepts the next incoming call and returns the new connection.
+func (l *UnixListener) AcceptUnix() (*UnixConn, error) {
+	c, err := l.listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	u, ok := c.(*UnixConn)
+	if !ok {
+		c.Close()
+		return nil, &net.OpError{
+			Op:   "accept",
+			Net:  l.addr.Network(),
+			Addr: &l.addr,
+			Err:  syscall.EAFNOSUPPORT,
+		}
 	}
+	return u, nil
+}
+
+func (l *UnixListener) Addr() net.Addr {
+	return &l.addr
+}
+
+func (l *UnixListener) Close() error {
+	err := l.listener.Close()
+	l.once.Do(func() {
+		if l.unlink && l.path != "" {
+			os.Remove(l.path)
+		}
+	})
+	return err
+}
+
+// SetDeadline sets the deadline associated with the listener. A zero time
+// value disables the deadline.
+func (l *UnixListener) SetDeadline(t time.Time) error {
+	if d, ok := l.Listener.(interface{ SetDeadline(time.Time) error }); ok {
+		return d.SetDeadline(t)
+	}
+	return syscall.ENOTSUP
+}
+
+// SetUnlinkOnClose sets whether the underlying socket file should be removed
+// from the file system when the listener is closed.
+//
+// The default behavior is to unlink the socket file only when the listener was
+// created by Listen.
+func (l *UnixListener) SetUnlinkOnClose(unlink bool) {
+	l.unlink = unlink
+}
+
+// File returns a copy of the underlying file.
+//
+// WASI preview 1 has no function to duplicate file descriptors, so File always
+// returns an error on runtimes which do not support it.
+func (l *UnixListener) File() (*os.File, error) {
+	if f, ok := l.Listener.(interface{ File() (*os.File, error) }); ok {
+		return f.File()
+	}
+	return nil, syscall.ENOSYS
+}
+
+func (l *UnixListener) SyscallConn() (syscall.RawConn, error) {
+	if sc, ok := l.Listener.(syscall.Conn); ok {
+		return sc.SyscallConn()
+	}
+	return nil, syscall.ENOTSUP
+}
+
+// DialUnix acts like Dial for unix networks.
+//
+// The network must be "unix" or "unixgram". If laddr is non-nil, it is used as
+// the local address for the connection.
+func DialUnix(network string, laddr, raddr *net.UnixAddr) (*UnixConn, error) {
+	switch network {
+	case "unix", "unixgram":
+	default:
+		return nil, unknownNetworkError("dial", network, unixOpAddr(raddr))
+	}
+	if raddr == nil {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: errMissingAddress}
+	}
+	var local net.Addr
+	if laddr != nil {
+		local = &net.UnixAddr{Name: laddr.Name, Net: network}
+	}
+	remote := &net.UnixAddr{Name: raddr.Name, Net: network}
+	c, err := dialAddr(context.Background(), local, remote)
+	if err != nil {
+		return nil, dialErr(remote, err)
+	}
+	return c.(*UnixConn), nil
+}
+
+// ListenUnix acts like Listen for unix networks.
+//
+// The network must be "unix".
+func ListenUnix(network string, laddr *net.UnixAddr) (*UnixListener, error) {
+	if network != "unix" {
+		return nil, unknownNetworkError("listen", network, unixOpAddr(laddr))
+	}
+	if laddr == nil {
+		return nil, &net.OpError{Op: "listen", Net: network, Err: errMissingAddress}
+	}
+	addr := &net.UnixAddr{Name: laddr.Name, Net: network}
+	var lc ListenConfig
+	l, err := lc.listenAddr(addr)
+	if err != nil {
+		return nil, listenErr(addr, err)
+	}
+	return l.(*UnixListener), nil
+}
+
+// ListenUnixgram acts like ListenPacket for unix networks.
+//
+// The network must be "unixgram".
+func ListenUnixgram(network string, laddr *net.UnixAddr) (*UnixConn, error) {
+	if network != "unixgram" {
+		return nil, unknownNetworkError("listen", network, unixOpAddr(laddr))
+	}
+	if laddr == nil {
+		return nil, &net.OpError{Op: "listen", Net: network, Err: errMissingAddress}
+	}
+	addr := &net.UnixAddr{Name: laddr.Name, Net: network}
+	c, err := listenPacketAddr(addr)
+	if err != nil {
+		return nil, listenErr(addr, err)
+	}
+	return c.(*UnixConn), nil
+}
+
+// unixOpAddr converts a to a net.Addr which is nil if a is nil, since the
+// methods of *net.UnixAddr cannot be called on a nil pointer.
+func unixOpAddr(a *net.UnixAddr) net.Addr {
+	if a == nil {
+		return nil
+	}
+	return a
 }
 
 type closeReader interface {
@@ -69,6 +394,10 @@ var (
 	_ closeReader = (*net.UnixConn)(nil)
 	_ closeWriter = (*net.UnixConn)(nil)
 
-	_ closeReader = (*unixConn)(nil)
-	_ closeWriter = (*unixConn)(nil)
+	_ closeReader = (*UnixConn)(nil)
+	_ closeWriter = (*UnixConn)(nil)
+
+	_ net.Conn       = (*UnixConn)(nil)
+	_ net.PacketConn = (*UnixConn)(nil)
+	_ net.Listener   = (*UnixListener)(nil)
 )
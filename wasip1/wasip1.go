@@ -0,0 +1,14 @@
+// Package wasip1 provides dial and listen functions using the WASI socket
+// extensions implemented by WasmEdge v0.12+ and wasi-go, which programs
+// compiled to GOOS=wasip1 can use in place of those of the standard library's
+// net package.
+//
+// The socket extensions encode IPv6 addresses as 16 bytes and have no
+// representation for the scope ID or flow info. The zones of IPv6 addresses
+// are dropped when they are passed to the host, so dialing a link-local
+// address such as fe80::1%eth0 only works when the host can select the
+// interface on its own, and the addresses returned by LocalAddr, RemoteAddr,
+// or the read functions of packet connections never have a zone.
+//
+// When compiling to other targets than GOOS=wasip1, this package is empty.
+package wasip1